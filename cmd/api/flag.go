@@ -3,13 +3,19 @@ package main
 import (
 	"flag"
 	"log"
+	"math"
+	"net"
+	"strings"
 	"time"
 
 	"gintama/internal/config"
+	"gintama/internal/lib/storage"
 )
 
 func parseFlag(cfg *config.Config) {
 	var machineID uint
+	var uploadAllowedTypes string
+	var trustedProxies string
 
 	// App
 	flag.UintVar(&machineID, "machine-id", 0, "Machine ID")
@@ -18,9 +24,77 @@ func parseFlag(cfg *config.Config) {
 	flag.IntVar(&cfg.App.Port, "port", 8080, "Port")
 	flag.StringVar(&cfg.App.Name, "app-name", "gofi", "App Name")
 	flag.StringVar(&cfg.App.JWTSecret, "jwt-secret", "", "JWT Secret")
+	flag.StringVar(&cfg.App.JWTKeysDir, "jwt-keys-dir", "", "Directory of PEM keys to sign JWT with instead of the secret")
+	flag.StringVar(&cfg.App.JWTSigningKeyID, "jwt-signing-key-id", "", "Key ID in jwt-keys-dir to sign with, defaults to the last private key by name")
+	flag.StringVar(&cfg.App.JWTAudience, "jwt-audience", "", "JWT audience, defaults to server-url")
+	flag.DurationVar(&cfg.App.JWTLeeway, "jwt-leeway", 30*time.Second, "Clock skew allowed when checking JWT expiry")
 	flag.StringVar(&cfg.App.ClientURL, "client-url", "", "Client URL")
 	flag.StringVar(&cfg.App.ServerURL, "server-url", "", "Server URL")
 
+	// Auth
+	flag.BoolVar(&cfg.Auth.RequireVerifiedAccount, "require-verified-account", true, "Reject sign in until the email address is verified")
+	flag.DurationVar(&cfg.Auth.AccessTokenExpiresIn, "access-token-expires-in", 15*time.Minute, "Access token lifetime")
+	flag.DurationVar(&cfg.Auth.RefreshTokenExpiresIn, "refresh-token-expires-in", 30*24*time.Hour, "Refresh token lifetime")
+	flag.DurationVar(&cfg.Auth.VerifyAccountExpiresIn, "verify-account-expires-in", 24*time.Hour, "Email verification link lifetime")
+	flag.DurationVar(&cfg.Auth.ResetPasswordExpiresIn, "reset-password-expires-in", time.Hour, "Password reset link lifetime")
+	flag.DurationVar(&cfg.Auth.VerifyResendCooldown, "verify-resend-cooldown", time.Minute, "Minimum wait between verification emails")
+	flag.IntVar(&cfg.Auth.VerifyResendDailyLimit, "verify-resend-daily-limit", 5, "Maximum verification emails per address per day")
+	flag.DurationVar(&cfg.Auth.ResetPasswordCooldown, "reset-password-cooldown", time.Minute, "Minimum wait between password reset emails")
+	flag.IntVar(&cfg.Auth.ResetPasswordDailyLimit, "reset-password-daily-limit", 5, "Maximum password reset emails per address per day")
+	flag.DurationVar(&cfg.Auth.TwoFactorChallengeExpiresIn, "two-factor-challenge-expires-in", 5*time.Minute, "Time allowed to enter the two-factor code after signing in")
+	flag.IntVar(&cfg.Auth.LockoutMaxAttempts, "lockout-max-attempts", 5, "Failed sign in attempts per account before it is locked")
+	flag.IntVar(&cfg.Auth.LockoutIPMaxAttempts, "lockout-ip-max-attempts", 50, "Failed sign in attempts per IP address before it is locked")
+	flag.DurationVar(&cfg.Auth.LockoutWindow, "lockout-window", 15*time.Minute, "Period failed sign in attempts are counted over")
+	flag.DurationVar(&cfg.Auth.LockoutDuration, "lockout-duration", 15*time.Minute, "How long sign in stays locked")
+	flag.DurationVar(&cfg.Auth.RevocationSyncInterval, "revocation-sync-interval", 5*time.Second, "How often revoked tokens are synced from the database")
+	flag.IntVar(&cfg.Auth.RevocationCacheSize, "revocation-cache-size", 100000, "Maximum revoked tokens kept in memory")
+	flag.DurationVar(&cfg.Auth.SessionTouchInterval, "session-touch-interval", time.Minute, "How often the last use of an active session or API key is recorded")
+	flag.DurationVar(&cfg.Auth.SessionIdleTimeout, "session-idle-timeout", 0, "Expire sessions after this long without activity (0 to disable)")
+	flag.DurationVar(&cfg.Auth.SessionMaxLifetime, "session-max-lifetime", 90*24*time.Hour, "Maximum time a sign-in can be kept alive by refreshing (0 to disable)")
+	flag.BoolVar(&cfg.Auth.SessionCookie, "session-cookie", false, "Hand out session tokens in HttpOnly cookies guarded by a CSRF token")
+	flag.StringVar(&cfg.Auth.CookieDomain, "cookie-domain", "", "Domain of the session cookies, leave empty for the API host only")
+	flag.StringVar(&cfg.Auth.CookieSameSite, "cookie-same-site", "lax", "SameSite attribute of the session cookies (lax|strict|none)")
+
+	// Password hashing
+	flag.UintVar(&cfg.Argon2.Memory, "argon2-memory", 64*1024, "Argon2 memory cost of new password hashes in KiB")
+	flag.UintVar(&cfg.Argon2.Iterations, "argon2-iterations", 10, "Argon2 iterations of new password hashes")
+	flag.UintVar(&cfg.Argon2.Parallelism, "argon2-parallelism", 2, "Argon2 lanes of new password hashes")
+
+	// Rate limit
+	flag.StringVar(&cfg.RateLimit.Store, "rate-limit-store", "memory", "Rate limit store (memory|postgres)")
+	flag.IntVar(&cfg.RateLimit.MemoryMaxKeys, "rate-limit-memory-max-keys", 100000, "Maximum clients tracked by the memory rate limit store")
+	flag.StringVar(&trustedProxies, "trusted-proxies", "", "Comma separated proxy IPs or CIDRs whose X-Forwarded-For is used as the client IP, leave empty to trust none")
+
+	// OpenID Connect
+	flag.StringVar(&cfg.OIDC.Name, "oidc-name", "", "OpenID Connect provider name used in routes, leave empty to disable")
+	flag.StringVar(&cfg.OIDC.Issuer, "oidc-issuer", "", "OpenID Connect issuer URL")
+	flag.StringVar(&cfg.OIDC.ClientID, "oidc-client-id", "", "OpenID Connect client ID")
+	flag.StringVar(&cfg.OIDC.ClientSecret, "oidc-client-secret", "", "OpenID Connect client secret")
+	flag.StringVar(&cfg.OIDC.RedirectURL, "oidc-redirect-url", "", "OpenID Connect redirect URL registered with the provider")
+	flag.DurationVar(&cfg.OIDC.StateExpiresIn, "oidc-state-expires-in", 10*time.Minute, "Time allowed to complete the provider sign in")
+
+	// Background jobs
+	flag.DurationVar(&cfg.Jobs.PurgeInterval, "purge-interval", time.Hour, "How often expired sessions and tokens are deleted")
+	flag.IntVar(&cfg.Jobs.PurgeBatchSize, "purge-batch-size", 1000, "Rows deleted per statement when purging expired rows")
+
+	// Storage
+	flag.StringVar(&cfg.Storage.Driver, "storage-driver", "local", "Upload storage driver (local|s3)")
+	flag.StringVar(&cfg.Storage.LocalDir, "storage-local-dir", "./storage", "Directory uploads are kept in by the local driver")
+	flag.StringVar(&cfg.Storage.S3Endpoint, "s3-endpoint", "", "S3 compatible endpoint URL")
+	flag.StringVar(&cfg.Storage.S3Region, "s3-region", "us-east-1", "S3 region")
+	flag.StringVar(&cfg.Storage.S3Bucket, "s3-bucket", "", "S3 bucket")
+	flag.StringVar(&cfg.Storage.S3AccessKey, "s3-access-key", "", "S3 access key")
+	flag.StringVar(&cfg.Storage.S3SecretKey, "s3-secret-key", "", "S3 secret key")
+	flag.BoolVar(&cfg.Storage.S3PathStyle, "s3-path-style", false, "Address the S3 bucket in the path, as MinIO expects")
+	flag.StringVar(&cfg.Storage.URLSecret, "storage-url-secret", "", "Secret signing download URLs of the local driver")
+	flag.DurationVar(&cfg.Storage.URLExpiresIn, "upload-url-expires-in", time.Hour, "Lifetime of upload download URLs")
+	flag.Int64Var(&cfg.Storage.MaxUploadSize, "upload-max-size", 10<<20, "Maximum upload size in bytes")
+	flag.StringVar(&uploadAllowedTypes, "upload-allowed-types", "image/jpeg,image/png,image/gif,image/webp,application/pdf", "Comma separated MIME types accepted for uploads, detected from the content")
+	flag.Int64Var(&cfg.Storage.ResumableMaxSize, "upload-resumable-max-size", 5<<30, "Maximum size in bytes of a file sent in chunks")
+	flag.DurationVar(&cfg.Storage.ResumableExpiresIn, "upload-resumable-expires-in", 24*time.Hour, "Time after the last chunk an unfinished resumable upload is aborted")
+	flag.Int64Var(&cfg.Storage.ChunkMaxSize, "upload-chunk-max-size", 64<<20, "Maximum size in bytes of a chunk of a resumable upload")
+	flag.DurationVar(&cfg.Storage.ChunkTimeout, "upload-chunk-timeout", 10*time.Minute, "Time allowed to receive a chunk of a resumable upload")
+
 	// Database
 	flag.StringVar(&cfg.DB.DSN, "db-dsn", "", "Database DSN")
 	flag.IntVar(&cfg.DB.MaxOpenConns, "db-max-open-conns", 25, "Database max open connections")
@@ -42,6 +116,18 @@ func parseFlag(cfg *config.Config) {
 
 	cfg.App.MachineID = uint16(machineID)
 
+	for _, mimeType := range strings.Split(uploadAllowedTypes, ",") {
+		if mimeType = strings.TrimSpace(mimeType); mimeType != "" {
+			cfg.Storage.AllowedTypes = append(cfg.Storage.AllowedTypes, mimeType)
+		}
+	}
+
+	for _, proxy := range strings.Split(trustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			cfg.RateLimit.TrustedProxies = append(cfg.RateLimit.TrustedProxies, proxy)
+		}
+	}
+
 	validateFlag(cfg)
 }
 
@@ -54,8 +140,12 @@ func validateFlag(cfg *config.Config) {
 		log.Fatal("flag machine-id must be provided and cannot be 0")
 	}
 
-	if cfg.App.JWTSecret == "" {
-		log.Fatal("flag jwt-secret must be provided")
+	if cfg.App.JWTSecret == "" && cfg.App.JWTKeysDir == "" {
+		log.Fatal("flag jwt-secret or jwt-keys-dir must be provided")
+	}
+
+	if cfg.App.JWTLeeway < 0 {
+		log.Fatal("flag jwt-leeway cannot be negative")
 	}
 
 	if cfg.App.ClientURL == "" {
@@ -66,6 +156,149 @@ func validateFlag(cfg *config.Config) {
 		log.Fatal("flag server-url must be provided")
 	}
 
+	if cfg.Auth.AccessTokenExpiresIn <= 0 {
+		log.Fatal("flag access-token-expires-in must be greater than 0")
+	}
+
+	if cfg.Auth.RefreshTokenExpiresIn <= cfg.Auth.AccessTokenExpiresIn {
+		log.Fatal("flag refresh-token-expires-in must be greater than access-token-expires-in")
+	}
+
+	if cfg.Auth.VerifyAccountExpiresIn <= 0 {
+		log.Fatal("flag verify-account-expires-in must be greater than 0")
+	}
+
+	if cfg.Auth.ResetPasswordExpiresIn <= 0 {
+		log.Fatal("flag reset-password-expires-in must be greater than 0")
+	}
+
+	if cfg.Auth.VerifyResendDailyLimit <= 0 {
+		log.Fatal("flag verify-resend-daily-limit must be greater than 0")
+	}
+
+	if cfg.Auth.ResetPasswordDailyLimit <= 0 {
+		log.Fatal("flag reset-password-daily-limit must be greater than 0")
+	}
+
+	if cfg.Auth.TwoFactorChallengeExpiresIn <= 0 {
+		log.Fatal("flag two-factor-challenge-expires-in must be greater than 0")
+	}
+
+	if cfg.Auth.LockoutMaxAttempts <= 0 || cfg.Auth.LockoutIPMaxAttempts <= 0 {
+		log.Fatal("flag lockout-max-attempts and lockout-ip-max-attempts must be greater than 0")
+	}
+
+	if cfg.Auth.LockoutWindow <= 0 || cfg.Auth.LockoutDuration <= 0 {
+		log.Fatal("flag lockout-window and lockout-duration must be greater than 0")
+	}
+
+	if cfg.Auth.RevocationSyncInterval <= 0 || cfg.Auth.RevocationCacheSize <= 0 {
+		log.Fatal("flag revocation-sync-interval and revocation-cache-size must be greater than 0")
+	}
+
+	if cfg.Auth.SessionTouchInterval <= 0 {
+		log.Fatal("flag session-touch-interval must be greater than 0")
+	}
+
+	if cfg.Auth.SessionIdleTimeout < 0 || (cfg.Auth.SessionIdleTimeout > 0 && cfg.Auth.SessionIdleTimeout <= cfg.Auth.SessionTouchInterval) {
+		log.Fatal("flag session-idle-timeout must be 0 or greater than session-touch-interval")
+	}
+
+	if cfg.Auth.SessionMaxLifetime < 0 || (cfg.Auth.SessionMaxLifetime > 0 && cfg.Auth.SessionMaxLifetime <= cfg.Auth.AccessTokenExpiresIn) {
+		log.Fatal("flag session-max-lifetime must be 0 or greater than access-token-expires-in")
+	}
+
+	if cfg.Auth.CookieSameSite != "lax" && cfg.Auth.CookieSameSite != "strict" && cfg.Auth.CookieSameSite != "none" {
+		log.Fatal("flag cookie-same-site must be lax, strict or none")
+	}
+
+	if cfg.Argon2.Iterations == 0 || cfg.Argon2.Iterations > math.MaxUint32 {
+		log.Fatal("flag argon2-iterations must be between 1 and 4294967295")
+	}
+
+	if cfg.Argon2.Parallelism == 0 || cfg.Argon2.Parallelism > math.MaxUint8 {
+		log.Fatal("flag argon2-parallelism must be between 1 and 255")
+	}
+
+	if cfg.Argon2.Memory < 8*cfg.Argon2.Parallelism || cfg.Argon2.Memory > math.MaxUint32 {
+		log.Fatal("flag argon2-memory must be at least 8 KiB per lane of argon2-parallelism")
+	}
+
+	switch cfg.Storage.Driver {
+	case "local":
+		if cfg.Storage.LocalDir == "" || cfg.Storage.URLSecret == "" {
+			log.Fatal("flag storage-local-dir and storage-url-secret must be provided when storage-driver is local")
+		}
+	case "s3":
+		if cfg.Storage.S3Endpoint == "" || cfg.Storage.S3Bucket == "" || cfg.Storage.S3AccessKey == "" || cfg.Storage.S3SecretKey == "" {
+			log.Fatal("flag s3-endpoint, s3-bucket, s3-access-key and s3-secret-key must be provided when storage-driver is s3")
+		}
+
+		if cfg.Storage.URLExpiresIn > 7*24*time.Hour {
+			log.Fatal("flag upload-url-expires-in must not exceed 168h with the s3 storage driver")
+		}
+	default:
+		log.Fatal("flag storage-driver must be local or s3")
+	}
+
+	if cfg.Storage.URLExpiresIn < time.Minute {
+		log.Fatal("flag upload-url-expires-in must be at least 1m")
+	}
+
+	if cfg.Storage.MaxUploadSize <= 0 {
+		log.Fatal("flag upload-max-size must be greater than 0")
+	}
+
+	if len(cfg.Storage.AllowedTypes) == 0 {
+		log.Fatal("flag upload-allowed-types must list at least one MIME type")
+	}
+
+	if cfg.Storage.ChunkMaxSize < storage.MinPartSize || cfg.Storage.ChunkMaxSize > 5<<30 {
+		log.Fatalf("flag upload-chunk-max-size must be between %d and %d", storage.MinPartSize, int64(5<<30))
+	}
+
+	// Every chunk but the last holds at least storage.MinPartSize bytes, and
+	// S3 joins at most 10000 parts.
+	if cfg.Storage.ResumableMaxSize <= 0 || cfg.Storage.ResumableMaxSize > 10000*storage.MinPartSize {
+		log.Fatalf("flag upload-resumable-max-size must be between 1 and %d", int64(10000*storage.MinPartSize))
+	}
+
+	if cfg.Storage.ResumableExpiresIn < time.Minute {
+		log.Fatal("flag upload-resumable-expires-in must be at least 1m")
+	}
+
+	if cfg.Storage.ChunkTimeout <= 0 {
+		log.Fatal("flag upload-chunk-timeout must be greater than 0")
+	}
+
+	if cfg.RateLimit.Store != "memory" && cfg.RateLimit.Store != "postgres" {
+		log.Fatal("flag rate-limit-store must be memory or postgres")
+	}
+
+	if cfg.RateLimit.MemoryMaxKeys <= 0 {
+		log.Fatal("flag rate-limit-memory-max-keys must be greater than 0")
+	}
+
+	for _, proxy := range cfg.RateLimit.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			log.Fatalf("flag trusted-proxies has an invalid IP or CIDR %q", proxy)
+		}
+	}
+
+	if cfg.OIDC.Name != "" {
+		if cfg.OIDC.Issuer == "" || cfg.OIDC.ClientID == "" || cfg.OIDC.RedirectURL == "" {
+			log.Fatal("flag oidc-issuer, oidc-client-id and oidc-redirect-url must be provided when oidc-name is set")
+		}
+
+		if cfg.OIDC.StateExpiresIn <= 0 {
+			log.Fatal("flag oidc-state-expires-in must be greater than 0")
+		}
+	}
+
+	if cfg.Jobs.PurgeInterval <= 0 || cfg.Jobs.PurgeBatchSize <= 0 {
+		log.Fatal("flag purge-interval and purge-batch-size must be greater than 0")
+	}
+
 	if cfg.DB.DSN == "" {
 		log.Fatal("flag db-dsn must be provided")
 	}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gintama/internal/app"
+	"gintama/internal/lib/scheduler"
+	"gintama/internal/repositories"
+)
+
+// emailCounterRetention keeps expired verifications and password resets for a
+// day, as their row also holds the send counters the daily limit is checked
+// against.
+const emailCounterRetention = 24 * time.Hour
+
+func jobs(app *app.Application) *scheduler.Scheduler {
+	s := scheduler.New(app.Repositories.AdvisoryLock, func(job string, err error) {
+		app.Logger.Error("scheduled job failed", "job", job, "error", err)
+	})
+
+	s.Add(scheduler.Job{
+		Name:     "purge-expired",
+		Interval: app.Config.Jobs.PurgeInterval,
+		Run: func(ctx context.Context) error {
+			return purgeExpired(ctx, app)
+		},
+	})
+
+	s.Add(scheduler.Job{
+		Name:     "abort-abandoned-uploads",
+		Interval: app.Config.Jobs.PurgeInterval,
+		Run: func(ctx context.Context) error {
+			return abortAbandonedUploads(ctx, app)
+		},
+	})
+
+	return s
+}
+
+// purgeExpired deletes rows that expired, in batches so no statement holds
+// locks on a large part of a table.
+func purgeExpired(ctx context.Context, app *app.Application) error {
+	now := time.Now()
+	idleSince := app.Config.Auth.SessionIdleSince(now)
+	repos := app.Repositories
+
+	tables := []struct {
+		name          string
+		deleteExpired func(limit int) (int64, error)
+	}{
+		{"sessions", func(limit int) (int64, error) {
+			return repos.Session.DeleteExpired(now, idleSince, limit)
+		}},
+		{"user_verify_accounts", func(limit int) (int64, error) {
+			return repos.UserVerifyAccount.DeleteExpired(now.Add(-emailCounterRetention), limit)
+		}},
+		{"user_reset_passwords", func(limit int) (int64, error) {
+			return repos.UserResetPassword.DeleteExpired(now.Add(-emailCounterRetention), limit)
+		}},
+		{"oidc_states", func(limit int) (int64, error) {
+			return repos.OIDCState.DeleteExpired(now, limit)
+		}},
+		{"rate_limits", func(limit int) (int64, error) {
+			return repos.RateLimit.DeleteExpired(now, limit)
+		}},
+		// Last, as deleting sessions can record revocations.
+		{"token_revocations", func(limit int) (int64, error) {
+			return repos.TokenRevocation.DeleteExpired(now, limit)
+		}},
+	}
+
+	var errs []error
+	for _, table := range tables {
+		var total int64
+		for ctx.Err() == nil {
+			deleted, err := table.deleteExpired(app.Config.Jobs.PurgeBatchSize)
+			if err != nil {
+				errs = append(errs, err)
+				break
+			}
+
+			total += deleted
+			if deleted < int64(app.Config.Jobs.PurgeBatchSize) {
+				break
+			}
+		}
+
+		if total > 0 {
+			app.Logger.Info("purged expired rows", "table", table.name, "count", total)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// abortAbandonedUploads deletes resumable uploads that received no chunk for
+// upload-resumable-expires-in once their parts are discarded in storage. An
+// upload whose parts fail to be discarded keeps its row, so the next run
+// retries it.
+func abortAbandonedUploads(ctx context.Context, app *app.Application) error {
+	var total int
+	var errs []error
+	for ctx.Err() == nil {
+		now := time.Now()
+
+		uploads, err := app.Repositories.ResumableUpload.ListExpired(now, app.Config.Jobs.PurgeBatchSize)
+		if err != nil {
+			return err
+		}
+
+		var failed bool
+		for _, upload := range uploads {
+			// Drivers report an unknown multipart upload as aborted.
+			if upload.MultipartID != "" {
+				err := app.Storage.AbortMultipart(ctx, upload.KeyFile, upload.MultipartID)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("abort multipart upload %s: %w", upload.ID, err))
+					failed = true
+					continue
+				}
+			}
+
+			err := app.Repositories.ResumableUpload.DeleteExpired(upload.ID, now)
+			if err != nil && !errors.Is(err, repositories.ErrRecordNotFound) {
+				errs = append(errs, err)
+				failed = true
+				continue
+			}
+
+			total++
+		}
+
+		// Failed uploads would be listed again, they wait for the next run.
+		if failed || len(uploads) < app.Config.Jobs.PurgeBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		app.Logger.Info("aborted abandoned uploads", "count", total)
+	}
+
+	return errors.Join(errs...)
+}
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"os"
 
 	"gintama/internal/app"
 	"gintama/internal/config"
+	"gintama/internal/lib/activity"
+	"gintama/internal/lib/argon2"
+	"gintama/internal/lib/jwt"
+	"gintama/internal/lib/oidc"
+	"gintama/internal/lib/ratelimit"
+	"gintama/internal/lib/revocation"
 	"gintama/internal/repositories"
 	"gintama/internal/services"
 )
@@ -31,14 +38,79 @@ func main() {
 	}
 	defer db.Close()
 
+	var keyRing *jwt.KeyRing
+	if cfg.App.JWTKeysDir != "" {
+		keyRing, err = jwt.LoadKeyRing(cfg.App.JWTKeysDir, cfg.App.JWTSigningKeyID)
+		if err != nil {
+			logger.Error("failed to load jwt keys", "error", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	objectStorage, err := openStorage(&cfg.Storage, cfg.App.ServerURL)
+	if err != nil {
+		logger.Error("failed to open storage", "error", err.Error())
+		os.Exit(1)
+	}
+
+	// Hashes made with other parameters are upgraded on the next sign in.
+	password := argon2.NewWithParams(argon2.Params{
+		Memory:     uint32(cfg.Argon2.Memory),
+		Iterations: uint32(cfg.Argon2.Iterations),
+		Parallel:   uint8(cfg.Argon2.Parallelism),
+	})
+
+	repos := repositories.New(db)
+	repos.UsePasswordHasher(password)
+
+	// Reads of an upload renew its download URL once it has expired.
+	repos.UseURLSigner(objectStorage, cfg.Storage.URLExpiresIn)
+
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore(cfg.RateLimit.MemoryMaxKeys)
+	if cfg.RateLimit.Store == "postgres" {
+		rateLimitStore = repos.RateLimit
+	}
+
+	// Revoked tokens are checked in memory, a failed first sync falls back to
+	// looking keys up in the database until a sync succeeds.
+	revocations := revocation.New(repos.TokenRevocation, cfg.Auth.RevocationCacheSize)
+	if err := revocations.Sync(); err != nil {
+		logger.Warn("failed to sync token revocations", "error", err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go revocations.Run(ctx, cfg.Auth.RevocationSyncInterval, func(err error) {
+		logger.Warn("failed to sync token revocations", "error", err.Error())
+	})
+
+	oidcProviders := make(map[string]oidc.Provider)
+	if cfg.OIDC.Name != "" {
+		oidcProviders[cfg.OIDC.Name] = oidc.New(oidc.Config{
+			Name:         cfg.OIDC.Name,
+			Issuer:       cfg.OIDC.Issuer,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+		})
+	}
+
 	// Dependencies Injection
 	app := &app.Application{
 		Config:       cfg,
 		Logger:       logger,
-		Repositories: repositories.New(db),
+		Repositories: repos,
 		Services: services.Services{
 			Email: services.EmailService{Config: cfg.Resend},
 		},
+		JWT:         jwt.New(&cfg.App, keyRing),
+		RateLimiter: ratelimit.New(rateLimitStore),
+		Revocations: revocations,
+		Activity:    activity.New(cfg.Auth.SessionTouchInterval),
+		OIDC:        oidcProviders,
+		Storage:     objectStorage,
+		Password:    password,
 	}
 
 	if err := serve(app); err != nil {
@@ -2,10 +2,12 @@ package main
 
 import (
 	"net/http"
+	"time"
 
 	"gintama/internal/app"
 	"gintama/internal/handlers"
 	"gintama/internal/lib/constant"
+	"gintama/internal/lib/ratelimit"
 	"gintama/internal/middlewares"
 
 	"github.com/gin-gonic/gin"
@@ -22,39 +24,115 @@ func routes(r *gin.Engine, app *app.Application) {
 	})
 
 	r.GET("/health-check", h.Health.Check)
+	r.GET("/.well-known/jwks.json", h.WellKnown.JWKS)
+
+	// Anonymous auth endpoints are limited per client address and authenticated
+	// routes per user. Sign up sends emails, so each client gets a few per hour
+	// and a higher cap across every client bounds the emails sent.
+	authLimit := m.RateLimit("auth", ratelimit.Policy{Limit: 20, Window: time.Minute}, ratelimit.ByIP)
+	signUpLimit := m.RateLimit("sign-up", ratelimit.Policy{Limit: 10, Window: time.Hour}, ratelimit.ByIP)
+	signUpGlobalLimit := m.RateLimit("sign-up-global", ratelimit.Policy{Limit: 60, Window: time.Minute}, ratelimit.ByRoute)
+	userLimit := m.RateLimit("user", ratelimit.Policy{Limit: 120, Window: time.Minute}, ratelimit.ByUID)
 
 	authRoutes := r.Group("/v1/auth")
-	authRoutes.POST("/sign-up", h.Auth.SignUp)
+	authRoutes.Use(authLimit)
+	authRoutes.POST("/sign-up", signUpLimit, signUpGlobalLimit, h.Auth.SignUp)
 	authRoutes.POST("/sign-in", h.Auth.SignIn)
+	authRoutes.POST("/refresh", h.Auth.Refresh)
 	authRoutes.POST("/verify-registration", h.Auth.VerifyRegistration)
+	authRoutes.POST("/resend-verification", h.Auth.ResendVerification)
+	authRoutes.POST("/forgot-password", h.Auth.ForgotPassword)
+	authRoutes.POST("/reset-password", h.Auth.ResetPassword)
 	authRoutes.GET("/verify-session", m.Authorization(), h.Auth.VerifySession)
-	authRoutes.POST("/sign-out", m.Authorization(), h.Auth.SignOut)
+	authRoutes.POST("/sign-out", m.Authorization(), m.RequireSession(), h.Auth.SignOut)
+	authRoutes.POST("/sign-out-all", m.Authorization(), m.RequireSession(), h.Auth.SignOutAll)
+	authRoutes.POST("/2fa/verify", h.Auth.VerifyTwoFactor)
+	authRoutes.POST("/2fa/setup", m.Authorization(), m.RequireSession(), h.TwoFactor.Setup)
+	authRoutes.POST("/2fa/confirm", m.Authorization(), m.RequireSession(), h.TwoFactor.Confirm)
+	authRoutes.POST("/2fa/disable", m.Authorization(), m.RequireSession(), h.TwoFactor.Disable)
+	authRoutes.GET("/oidc/:provider/authorize", h.Auth.OIDCAuthorize)
+	authRoutes.POST("/oidc/:provider/callback", h.Auth.OIDCCallback)
+
+	meRoutes := r.Group("/v1/me")
+	meRoutes.Use(m.Authorization(), userLimit)
+	meRoutes.GET("", h.User.ShowOwn)
+	meRoutes.PATCH("", m.RequireSession(), h.User.UpdateOwn)
+	meRoutes.DELETE("", m.RequireSession(), h.Auth.DeleteAccount)
+	meRoutes.POST("/change-password", m.RequireSession(), h.Auth.ChangePassword)
+	meRoutes.GET("/sessions", m.RequireSession(), h.Session.IndexOwn)
+	meRoutes.DELETE("/sessions/:sessionID", m.RequireSession(), h.Session.DeleteOwn)
+	meRoutes.PUT("/avatar", m.RequireSession(), h.Upload.PutAvatar)
+
+	// API keys cannot manage API keys, so a leaked key cannot outlive its own
+	// revocation.
+	apiKeyRoutes := meRoutes.Group("/api-keys")
+	apiKeyRoutes.Use(m.RequireSession())
+	apiKeyRoutes.GET("", h.APIKey.IndexOwn)
+	apiKeyRoutes.GET("/:apiKeyID", h.APIKey.ShowOwn)
+	apiKeyRoutes.POST("", h.APIKey.CreateOwn)
+	apiKeyRoutes.PUT("/:apiKeyID", h.APIKey.UpdateOwn)
+	apiKeyRoutes.DELETE("/:apiKeyID", h.APIKey.DeleteOwn)
+
+	// Signed download URLs of the local storage driver.
+	r.GET(localFilesPath+"/*key", h.File.Download)
 
-	adminOnly := []string{constant.RoleAdmin}
+	uploadRoutes := r.Group("/v1/uploads")
+	uploadRoutes.Use(m.Authorization(), userLimit, m.RequirePermission(constant.PermissionUploadsWrite))
+	uploadRoutes.POST("", h.Upload.Create)
+	uploadRoutes.GET("/:uploadID", h.Upload.Show)
+	uploadRoutes.DELETE("/:uploadID", h.Upload.Delete)
+
+	// Files larger than upload-max-size are sent in chunks.
+	resumableUploadRoutes := r.Group("/v1/resumable-uploads")
+	resumableUploadRoutes.Use(m.Authorization(), userLimit, m.RequirePermission(constant.PermissionUploadsWrite))
+	resumableUploadRoutes.POST("", h.ResumableUpload.Create)
+	resumableUploadRoutes.GET("/:uploadID", h.ResumableUpload.Show)
+	resumableUploadRoutes.PATCH("/:uploadID", h.ResumableUpload.Patch)
+	resumableUploadRoutes.DELETE("/:uploadID", h.ResumableUpload.Delete)
 
 	sessionRoutes := r.Group("/v1/sessions")
-	sessionRoutes.Use(m.Authorization(), m.PermissionAccess(adminOnly))
+	sessionRoutes.Use(m.Authorization(), userLimit, m.RequirePermission(constant.PermissionSessionsRead))
 	sessionRoutes.GET("", h.Session.Index)
 
+	revocationRoutes := r.Group("/v1/revocations")
+	revocationRoutes.Use(m.Authorization(), userLimit, m.RequirePermission(constant.PermissionSessionsWrite))
+	revocationRoutes.POST("/users/:userID", h.Revocation.RevokeUser)
+	revocationRoutes.POST("/sessions/:sessionID", h.Revocation.RevokeSession)
+	revocationRoutes.POST("/tokens/:jti", h.Revocation.RevokeToken)
+
+	permissionRoutes := r.Group("/v1/permissions")
+	permissionRoutes.Use(m.Authorization(), userLimit)
+	permissionRoutes.GET("", m.RequirePermission(constant.PermissionPermissionsRead), h.Permission.Index)
+	permissionRoutes.GET("/:permissionID", m.RequirePermission(constant.PermissionPermissionsRead), h.Permission.Show)
+	permissionRoutes.POST("", m.RequirePermission(constant.PermissionPermissionsWrite), h.Permission.Create)
+	permissionRoutes.PUT("/:permissionID", m.RequirePermission(constant.PermissionPermissionsWrite), h.Permission.Update)
+	permissionRoutes.DELETE("/:permissionID", m.RequirePermission(constant.PermissionPermissionsWrite), h.Permission.Delete)
+
 	roleRoutes := r.Group("/v1/roles")
-	roleRoutes.Use(m.Authorization())
-	roleRoutes.GET("", h.Role.Index)
-	roleRoutes.GET("/:roleID", h.Role.Show)
-	roleRoutes.POST("", m.PermissionAccess(adminOnly), h.Role.Create)
-	roleRoutes.PUT("/:roleID", m.PermissionAccess(adminOnly), h.Role.Update)
-	roleRoutes.DELETE("/:roleID", m.PermissionAccess(adminOnly), h.Role.Delete)
-	roleRoutes.DELETE("/:roleID/soft-delete", m.PermissionAccess(adminOnly), h.Role.SoftDelete)
-	roleRoutes.PATCH("/:roleID/restore", m.PermissionAccess(adminOnly), h.Role.Restore)
+	roleRoutes.Use(m.Authorization(), userLimit)
+	roleRoutes.GET("", m.RequirePermission(constant.PermissionRolesRead), h.Role.Index)
+	roleRoutes.GET("/:roleID", m.RequirePermission(constant.PermissionRolesRead), h.Role.Show)
+	roleRoutes.POST("", m.RequirePermission(constant.PermissionRolesWrite), h.Role.Create)
+	roleRoutes.PUT("/:roleID", m.RequirePermission(constant.PermissionRolesWrite), h.Role.Update)
+	roleRoutes.DELETE("/:roleID", m.RequirePermission(constant.PermissionRolesWrite), h.Role.Delete)
+	roleRoutes.DELETE("/:roleID/soft-delete", m.RequirePermission(constant.PermissionRolesWrite), h.Role.SoftDelete)
+	roleRoutes.PATCH("/:roleID/restore", m.RequirePermission(constant.PermissionRolesWrite), h.Role.Restore)
+	roleRoutes.GET("/:roleID/permissions", m.RequirePermission(constant.PermissionRolesRead), h.Role.Permissions)
+	roleRoutes.PUT("/:roleID/permissions/:permissionID", m.RequirePermission(constant.PermissionRolesWrite), h.Role.AttachPermission)
+	roleRoutes.DELETE("/:roleID/permissions/:permissionID", m.RequirePermission(constant.PermissionRolesWrite), h.Role.DetachPermission)
 
 	userRoutes := r.Group("/v1/users")
-	userRoutes.Use(m.Authorization())
-	userRoutes.GET("", h.User.Index)
-	userRoutes.GET("/:userID", h.User.Show)
-	userRoutes.POST("", m.PermissionAccess(adminOnly), h.User.Create)
-	userRoutes.PUT("/:userID", m.PermissionAccess(adminOnly), h.User.Update)
-	userRoutes.DELETE("/:userID", m.PermissionAccess(adminOnly), h.User.Delete)
-	userRoutes.DELETE("/:userID/soft-delete", m.PermissionAccess(adminOnly), h.User.SoftDelete)
-	userRoutes.PATCH("/:userID/restore", m.PermissionAccess(adminOnly), h.User.Restore)
+	userRoutes.Use(m.Authorization(), userLimit)
+	userRoutes.GET("", m.RequirePermission(constant.PermissionUsersRead), h.User.Index)
+	userRoutes.GET("/:userID", m.RequirePermission(constant.PermissionUsersRead), h.User.Show)
+	userRoutes.POST("", m.RequirePermission(constant.PermissionUsersWrite), h.User.Create)
+	userRoutes.PUT("/:userID", m.RequirePermission(constant.PermissionUsersWrite), h.User.Update)
+	userRoutes.DELETE("/:userID", m.RequirePermission(constant.PermissionUsersWrite), h.User.Delete)
+	userRoutes.DELETE("/:userID/soft-delete", m.RequirePermission(constant.PermissionUsersWrite), h.User.SoftDelete)
+	userRoutes.PATCH("/:userID/restore", m.RequirePermission(constant.PermissionUsersWrite), h.User.Restore)
+	userRoutes.PATCH("/:userID/block", m.RequirePermission(constant.PermissionUsersWrite), h.User.Block)
+	userRoutes.PATCH("/:userID/unblock", m.RequirePermission(constant.PermissionUsersWrite), h.User.Unblock)
+	userRoutes.DELETE("/:userID/lockout", m.RequirePermission(constant.PermissionUsersWrite), h.User.Unlock)
 
 	// Not found handler
 	r.NoRoute(func(c *gin.Context) {
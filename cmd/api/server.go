@@ -10,8 +10,9 @@ import (
 	"time"
 
 	"gintama/internal/app"
-	"gintama/internal/lib"
 	"gintama/internal/lib/constant"
+	"gintama/internal/lib/csrf"
+	"gintama/internal/lib/ratelimit"
 
 	helmet "github.com/danielkov/gin-helmet/ginhelmet"
 	"github.com/gin-contrib/cors"
@@ -23,18 +24,26 @@ import (
 func serve(app *app.Application) error {
 	server := gin.Default()
 
+	// Rate limits key clients by IP, X-Forwarded-For is only honoured from
+	// the configured proxies so clients cannot pick their own key.
+	if err := server.SetTrustedProxies(app.Config.RateLimit.TrustedProxies); err != nil {
+		return err
+	}
+
 	// Middleware
 	server.Use(gzip.Gzip(gzip.DefaultCompression))
 	server.Use(helmet.Default())
 	server.Use(requestid.New())
-	server.Use(lib.RateLimiter())
+	server.Use(app.RateLimiter.Handler("global", ratelimit.Policy{Limit: 300, Window: time.Minute}, ratelimit.ByIP))
 
 	// Cors
 	server.Use(cors.New(cors.Config{
-		AllowOrigins: constant.AllowedOrigins(app),
-		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		MaxAge:       3600,
+		AllowOrigins:     constant.AllowedOrigins(app),
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key", csrf.HeaderName, "Upload-Offset"},
+		ExposeHeaders:    []string{"Upload-Offset"},
+		AllowCredentials: app.Config.Auth.SessionCookie,
+		MaxAge:           3600,
 	}))
 
 	// static file
@@ -52,6 +61,10 @@ func serve(app *app.Application) error {
 		WriteTimeout: 3 * time.Minute,
 	}
 
+	// Background jobs
+	scheduler := jobs(app)
+	scheduler.Start(context.Background())
+
 	// Start server in a goroutine
 	go func() {
 		app.Logger.Info("server started on port", "port", app.Config.App.Port)
@@ -73,7 +86,12 @@ func serve(app *app.Application) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := srv.Shutdown(ctx); err != nil {
+	err := srv.Shutdown(ctx)
+
+	// Jobs stop after the server so requests in flight still see their rows.
+	scheduler.Stop()
+
+	if err != nil {
 		app.Logger.Error("server forced to shutdown", "error", err)
 		return err
 	}
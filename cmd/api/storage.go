@@ -0,0 +1,26 @@
+package main
+
+import (
+	"strings"
+
+	"gintama/internal/config"
+	"gintama/internal/lib/storage"
+)
+
+// localFilesPath is the route serving signed URLs of the local driver.
+const localFilesPath = "/v1/files"
+
+func openStorage(cfg *config.ConfigStorage, serverURL string) (storage.Storage, error) {
+	if cfg.Driver == "s3" {
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+		})
+	}
+
+	return storage.NewLocal(cfg.LocalDir, strings.TrimSuffix(serverURL, "/")+localFilesPath, []byte(cfg.URLSecret))
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"gintama/internal/lib/argon2"
+)
+
+// calibrate measures argon2 on the machine it runs on and prints the flags of
+// the api for hashes that take about target. Run it on the production hardware.
+func main() {
+	var (
+		target      time.Duration
+		memory      uint
+		parallelism uint
+	)
+
+	defaults := argon2.DefaultParams()
+
+	flag.DurationVar(&target, "target", 500*time.Millisecond, "Time a single password hash should take")
+	flag.UintVar(&memory, "memory", uint(defaults.Memory), "Argon2 memory cost in KiB, lowered only when a single iteration exceeds target")
+	flag.UintVar(&parallelism, "parallelism", uint(defaults.Parallel), "Argon2 lanes")
+
+	flag.Parse()
+
+	if target <= 0 {
+		log.Fatal("flag target must be greater than 0")
+	}
+
+	if parallelism == 0 || parallelism > math.MaxUint8 {
+		log.Fatal("flag parallelism must be between 1 and 255")
+	}
+
+	if memory < 8*parallelism || memory > math.MaxUint32 {
+		log.Fatal("flag memory must be at least 8 KiB per lane of parallelism")
+	}
+
+	fmt.Printf("Calibrating argon2 for %s...\n", target)
+
+	calibration, err := argon2.Calibrate(target, uint32(memory), uint8(parallelism))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	params := calibration.Params
+
+	if calibration.Duration > target {
+		fmt.Printf("Warning: the cheapest parameters still take %s\n", calibration.Duration)
+	}
+
+	fmt.Printf("One hash takes %s with:\n", calibration.Duration.Round(time.Millisecond))
+	fmt.Printf("  --argon2-memory=%d --argon2-iterations=%d --argon2-parallelism=%d\n", params.Memory, params.Iterations, params.Parallel)
+}
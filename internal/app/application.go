@@ -4,6 +4,13 @@ import (
 	"log/slog"
 
 	"gintama/internal/config"
+	"gintama/internal/lib/activity"
+	"gintama/internal/lib/argon2"
+	"gintama/internal/lib/jwt"
+	"gintama/internal/lib/oidc"
+	"gintama/internal/lib/ratelimit"
+	"gintama/internal/lib/revocation"
+	"gintama/internal/lib/storage"
 	"gintama/internal/repositories"
 	"gintama/internal/services"
 )
@@ -13,4 +20,11 @@ type Application struct {
 	Logger       *slog.Logger
 	Repositories repositories.Repositories
 	Services     services.Services
+	JWT          *jwt.JWT
+	RateLimiter  *ratelimit.Limiter
+	Revocations  *revocation.Store
+	Activity     *activity.Throttle
+	OIDC         map[string]oidc.Provider
+	Storage      storage.Storage
+	Password     *argon2.Argon2
 }
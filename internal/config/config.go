@@ -3,20 +3,102 @@ package config
 import "time"
 
 type Config struct {
-	App    ConfigApp
-	DB     ConfigDB
-	Resend ConfigResend
+	App       ConfigApp
+	Auth      ConfigAuth
+	Argon2    ConfigArgon2
+	RateLimit ConfigRateLimit
+	OIDC      ConfigOIDC
+	Jobs      ConfigJobs
+	Storage   ConfigStorage
+	DB        ConfigDB
+	Resend    ConfigResend
 }
 
 type ConfigApp struct {
-	Env       string
-	Debug     bool
-	Port      int
-	MachineID uint16
-	Name      string
-	JWTSecret string
-	ClientURL string
-	ServerURL string
+	Env             string
+	Debug           bool
+	Port            int
+	MachineID       uint16
+	Name            string
+	JWTSecret       string
+	JWTKeysDir      string
+	JWTSigningKeyID string
+	JWTAudience     string
+	JWTLeeway       time.Duration
+	ClientURL       string
+	ServerURL       string
+}
+
+type ConfigAuth struct {
+	RequireVerifiedAccount      bool
+	AccessTokenExpiresIn        time.Duration
+	RefreshTokenExpiresIn       time.Duration
+	VerifyAccountExpiresIn      time.Duration
+	ResetPasswordExpiresIn      time.Duration
+	VerifyResendCooldown        time.Duration
+	VerifyResendDailyLimit      int
+	ResetPasswordCooldown       time.Duration
+	ResetPasswordDailyLimit     int
+	TwoFactorChallengeExpiresIn time.Duration
+	LockoutMaxAttempts          int
+	LockoutIPMaxAttempts        int
+	LockoutWindow               time.Duration
+	LockoutDuration             time.Duration
+	RevocationSyncInterval      time.Duration
+	RevocationCacheSize         int
+	SessionTouchInterval        time.Duration
+	SessionIdleTimeout          time.Duration
+	SessionMaxLifetime          time.Duration
+	SessionCookie               bool
+	CookieDomain                string
+	CookieSameSite              string
+}
+
+// ConfigArgon2 are the cost parameters of new password hashes. Memory is in KiB.
+type ConfigArgon2 struct {
+	Memory      uint
+	Iterations  uint
+	Parallelism uint
+}
+
+type ConfigRateLimit struct {
+	Store          string
+	MemoryMaxKeys  int
+	TrustedProxies []string // nil trusts no proxy, clients are keyed by the remote address
+}
+
+type ConfigOIDC struct {
+	Name           string
+	Issuer         string
+	ClientID       string
+	ClientSecret   string
+	RedirectURL    string
+	StateExpiresIn time.Duration
+}
+
+type ConfigJobs struct {
+	PurgeInterval  time.Duration
+	PurgeBatchSize int
+}
+
+type ConfigStorage struct {
+	Driver        string
+	LocalDir      string
+	S3Endpoint    string
+	S3Region      string
+	S3Bucket      string
+	S3AccessKey   string
+	S3SecretKey   string
+	S3PathStyle   bool
+	URLSecret     string
+	URLExpiresIn  time.Duration
+	MaxUploadSize int64
+	AllowedTypes  []string
+	// Resumable uploads
+	ResumableMaxSize   int64
+	ResumableExpiresIn time.Duration
+	ChunkMaxSize       int64
+	ChunkTimeout       time.Duration
 }
 
 type ConfigDB struct {
@@ -31,3 +113,13 @@ type ConfigResend struct {
 	FromEmail    string
 	DebugToEmail string
 }
+
+// SessionIdleSince returns the time a session must have been seen after to
+// still be alive at now, or the zero time when sessions never go idle.
+func (c ConfigAuth) SessionIdleSince(now time.Time) time.Time {
+	if c.SessionIdleTimeout <= 0 {
+		return time.Time{}
+	}
+
+	return now.Add(-c.SessionIdleTimeout)
+}
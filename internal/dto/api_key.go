@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"time"
+
+	"gintama/internal/lib/validator"
+)
+
+type APIKeyCreate struct {
+	Name      string     `json:"name" form:"name"`
+	Scopes    []string   `json:"scopes" form:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at" form:"expires_at"`
+}
+
+func (dto APIKeyCreate) Validate(v *validator.MapValidator) {
+	v.Field("name").Required().String().MaxRune(100)
+	v.Field("scopes").Slice(func(v *validator.FieldValidator) {
+		v.String().Regex(`^[a-z0-9-]+:[a-z0-9-]+$`)
+	})
+	v.Field("expires_at").Date()
+}
+
+type APIKeyUpdate struct {
+	Name      string     `json:"name" form:"name"`
+	Scopes    *[]string  `json:"scopes" form:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at" form:"expires_at"`
+}
+
+func (dto APIKeyUpdate) Validate(v *validator.MapValidator) {
+	v.Field("name").String().MaxRune(100)
+	v.Field("scopes").Slice(func(v *validator.FieldValidator) {
+		v.String().Regex(`^[a-z0-9-]+:[a-z0-9-]+$`)
+	})
+	v.Field("expires_at").Date()
+}
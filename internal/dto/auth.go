@@ -35,3 +35,79 @@ type AuthVerifyRegistration struct {
 func (dto AuthVerifyRegistration) Validate(v *validator.MapValidator) {
 	v.Field("token").Required().String()
 }
+
+type AuthRefresh struct {
+	RefreshToken string `json:"refresh_token" form:"refresh_token"`
+}
+
+func (dto AuthRefresh) Validate(v *validator.MapValidator) {
+	v.Field("refresh_token").Required().String()
+}
+
+type AuthForgotPassword struct {
+	Email string `json:"email" form:"email"`
+}
+
+func (dto AuthForgotPassword) Validate(v *validator.MapValidator) {
+	v.Field("email").Required().String().Email()
+}
+
+type AuthResetPassword struct {
+	Token    string `json:"token" form:"token"`
+	Password string `json:"password" form:"password"`
+}
+
+func (dto AuthResetPassword) Validate(v *validator.MapValidator) {
+	v.Field("token").Required().String()
+	v.Field("password").Required().String()
+}
+
+type AuthResendVerification struct {
+	Email string `json:"email" form:"email"`
+}
+
+func (dto AuthResendVerification) Validate(v *validator.MapValidator) {
+	v.Field("email").Required().String().Email()
+}
+
+type AuthVerifyTwoFactor struct {
+	ChallengeToken string  `json:"challenge_token" form:"challenge_token"`
+	Code           *string `json:"code" form:"code"`
+	RecoveryCode   *string `json:"recovery_code" form:"recovery_code"`
+}
+
+func (dto AuthVerifyTwoFactor) Validate(v *validator.MapValidator) {
+	v.Field("challenge_token").Required().String()
+	v.Field("code").String()
+	v.Field("recovery_code").String()
+}
+
+type AuthOIDCCallback struct {
+	Code  string `json:"code" form:"code"`
+	State string `json:"state" form:"state"`
+}
+
+func (dto AuthOIDCCallback) Validate(v *validator.MapValidator) {
+	v.Field("code").Required().String()
+	v.Field("state").Required().String()
+}
+
+type AuthChangePassword struct {
+	CurrentPassword string `json:"current_password" form:"current_password"`
+	Password        string `json:"password" form:"password"`
+}
+
+func (dto AuthChangePassword) Validate(v *validator.MapValidator) {
+	v.Field("current_password").Required().String()
+	v.Field("password").Required().String()
+}
+
+// AuthDeleteAccount confirms the deletion with the password, which accounts
+// only signing in with a provider do not have.
+type AuthDeleteAccount struct {
+	Password string `json:"password" form:"password"`
+}
+
+func (dto AuthDeleteAccount) Validate(v *validator.MapValidator) {
+	v.Field("password").String()
+}
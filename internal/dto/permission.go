@@ -0,0 +1,33 @@
+package dto
+
+import "gintama/internal/lib/validator"
+
+type PermissionPagination struct {
+	Offset int64 `json:"offset" form:"offset"`
+	Limit  int64 `json:"limit" form:"limit"`
+}
+
+func (dto PermissionPagination) Validate(v *validator.MapValidator) {
+	v.Field("offset").Required().Num()
+	v.Field("limit").Required().Num()
+}
+
+type PermissionCreate struct {
+	Name        string  `json:"name" form:"name"`
+	Description *string `json:"description" form:"description"`
+}
+
+func (dto PermissionCreate) Validate(v *validator.MapValidator) {
+	v.Field("name").Required().String().Regex(`^[a-z0-9-]+:[a-z0-9-]+$`)
+	v.Field("description").String()
+}
+
+type PermissionUpdate struct {
+	Name        string  `json:"name" form:"name"`
+	Description *string `json:"description" form:"description"`
+}
+
+func (dto PermissionUpdate) Validate(v *validator.MapValidator) {
+	v.Field("name").String().Regex(`^[a-z0-9-]+:[a-z0-9-]+$`)
+	v.Field("description").String()
+}
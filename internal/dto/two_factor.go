@@ -0,0 +1,19 @@
+package dto
+
+import "gintama/internal/lib/validator"
+
+type TwoFactorConfirm struct {
+	Code string `json:"code" form:"code"`
+}
+
+func (dto TwoFactorConfirm) Validate(v *validator.MapValidator) {
+	v.Field("code").Required().String()
+}
+
+type TwoFactorDisable struct {
+	Code string `json:"code" form:"code"`
+}
+
+func (dto TwoFactorDisable) Validate(v *validator.MapValidator) {
+	v.Field("code").Required().String()
+}
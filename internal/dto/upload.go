@@ -0,0 +1,15 @@
+package dto
+
+import "gintama/internal/lib/validator"
+
+type ResumableUploadCreate struct {
+	FileName string `json:"file_name" form:"file_name"`
+	Size     int64  `json:"size" form:"size"`
+	Checksum string `json:"checksum" form:"checksum"` // SHA-256 of the whole file, hex encoded
+}
+
+func (dto ResumableUploadCreate) Validate(v *validator.MapValidator) {
+	v.Field("file_name").Required().String().MaxRune(255)
+	v.Field("size").Required().Num().Min(1)
+	v.Field("checksum").Required().String().MinS(64).MaxS(64)
+}
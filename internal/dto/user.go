@@ -55,3 +55,17 @@ func (dto UserUpdate) Validate(v *validator.MapValidator) {
 	v.Field("role_id").UUID()
 	v.Field("upload_id").UUID()
 }
+
+// UserUpdateOwn holds the profile fields users edit themselves. An empty
+// last_name or phone clears it.
+type UserUpdateOwn struct {
+	FirstName string  `json:"first_name" form:"first_name"`
+	LastName  *string `json:"last_name" form:"last_name"`
+	Phone     *string `json:"phone" form:"phone"`
+}
+
+func (dto UserUpdateOwn) Validate(v *validator.MapValidator) {
+	v.Field("first_name").String().MaxRune(100)
+	v.Field("last_name").String().MaxRune(100)
+	v.Field("phone").String().MaxRune(20)
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"gintama/internal/app"
+	"gintama/internal/dto"
+	"gintama/internal/lib"
+	"gintama/internal/models"
+	"gintama/internal/repositories"
+	"gintama/internal/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// apiKeyPrefix starts every API key so leaked keys are easy to recognize, and
+// apiKeyPrefixLength characters of the key are kept to tell keys apart.
+const (
+	apiKeyPrefix       = "gtm_"
+	apiKeyPrefixLength = len(apiKeyPrefix) + 8
+)
+
+type apiKeyHandler struct {
+	app *app.Application
+}
+
+func (h *apiKeyHandler) IndexOwn(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	apiKeys, err := h.app.Repositories.APIKey.ListByUserID(uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseMultiData[*models.APIKey]{
+		Message: "list data has been retrieved successfully",
+		Data:    apiKeys,
+		Meta: gin.H{
+			"total": len(apiKeys),
+		},
+	})
+}
+
+func (h *apiKeyHandler) ShowOwn(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	apiKeyID, err := lib.ContextParamUUID(c, "apiKeyID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid api key id must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	apiKey, err := h.app.Repositories.APIKey.Get(uid, apiKeyID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[*models.APIKey]{
+		Message: "get data has been retrieved successfully",
+		Data:    apiKey,
+	})
+}
+
+// CreateOwn issues a key acting as the caller. The key itself is only part of
+// this response, the database keeps its digest.
+func (h *apiKeyHandler) CreateOwn(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	var dto dto.APIKeyCreate
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			c.JSON(http.StatusBadRequest, lib.WrapValidationError(e.MessageRecord))
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	apiKey := &models.APIKey{
+		ID:        uuid.Must(uuid.NewV7()),
+		UserID:    uid,
+		Name:      dto.Name,
+		Scopes:    normalizeScopes(dto.Scopes),
+		ExpiresAt: dto.ExpiresAt,
+	}
+
+	if !h.validateAPIKey(c, apiKey) {
+		return
+	}
+
+	secret, err := lib.RandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	key := apiKeyPrefix + secret
+	apiKey.Prefix = key[:apiKeyPrefixLength]
+	apiKey.KeyHash = lib.HashToken(key)
+
+	err = h.app.Repositories.APIKey.Insert(apiKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[any]{
+		Message: "API key created, copy it now as it will not be shown again",
+		Data: gin.H{
+			"api_key": apiKey,
+			"key":     key,
+		},
+	})
+}
+
+func (h *apiKeyHandler) UpdateOwn(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	apiKeyID, err := lib.ContextParamUUID(c, "apiKeyID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid api key id must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var dto dto.APIKeyUpdate
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			c.JSON(http.StatusBadRequest, lib.WrapValidationError(e.MessageRecord))
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	apiKey, err := h.app.Repositories.APIKey.Get(uid, apiKeyID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	if dto.Name != "" {
+		apiKey.Name = dto.Name
+	}
+
+	if dto.Scopes != nil {
+		apiKey.Scopes = normalizeScopes(*dto.Scopes)
+	}
+
+	if dto.ExpiresAt != nil {
+		apiKey.ExpiresAt = dto.ExpiresAt
+	}
+
+	if !h.validateAPIKey(c, apiKey) {
+		return
+	}
+
+	err = h.app.Repositories.APIKey.Update(apiKey)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[*models.APIKey]{
+		Message: "data has been updated successfully",
+		Data:    apiKey,
+	})
+}
+
+func (h *apiKeyHandler) DeleteOwn(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	apiKeyID, err := lib.ContextParamUUID(c, "apiKeyID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid api key id must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	err = h.app.Repositories.APIKey.Delete(uid, apiKeyID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API key revoked successfully",
+	})
+}
+
+// validateAPIKey checks the expiry and that the owner holds every scope, as a
+// key can never do more than its owner. It responds and returns false when the
+// key is not valid.
+func (h *apiKeyHandler) validateAPIKey(c *gin.Context, apiKey *models.APIKey) bool {
+	if apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "expires_at must be in the future"})
+		return false
+	}
+
+	for _, scope := range apiKey.Scopes {
+		allowed, err := h.app.Repositories.RolePermission.UserHas(apiKey.UserID, scope)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return false
+		}
+
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": fmt.Sprintf("Forbidden, you do not have the %s permission", scope),
+			})
+			return false
+		}
+	}
+
+	return true
+}
+
+func normalizeScopes(scopes []string) []string {
+	normalized := slices.Clone(scopes)
+	if normalized == nil {
+		normalized = []string{}
+	}
+
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
@@ -2,18 +2,24 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
-	"strings"
+	"strconv"
 	"time"
 
 	"gintama/internal/app"
 	"gintama/internal/dto"
 	"gintama/internal/lib"
-	"gintama/internal/lib/argon2"
 	"gintama/internal/lib/constant"
+	"gintama/internal/lib/csrf"
 	"gintama/internal/lib/jwt"
+	"gintama/internal/lib/lockout"
+	"gintama/internal/lib/revocation"
+	"gintama/internal/lib/totp"
 	"gintama/internal/models"
+	"gintama/internal/repositories"
 	"gintama/internal/services"
 	"gintama/internal/types"
 
@@ -21,6 +27,12 @@ import (
 	"github.com/google/uuid"
 )
 
+// emailLimitWindow is the period the daily limits of verification and password
+// reset emails apply to.
+const emailLimitWindow = 24 * time.Hour
+
+var errEmailLimited = errors.New("email send limit reached")
+
 type authHandler struct {
 	app *app.Application
 }
@@ -51,9 +63,10 @@ func (h *authHandler) SignUp(c *gin.Context) {
 	}
 
 	userVerifyAccount := &models.UserVerifyAccount{}
+	var token string
 
 	err := lib.WithTransaction(h.app.Repositories.User.DB, func(tx *sql.Tx) error {
-		err := user.BeforeCreate()
+		err := user.BeforeCreate(h.app.Password)
 		if err != nil {
 			return err
 		}
@@ -63,19 +76,20 @@ func (h *authHandler) SignUp(c *gin.Context) {
 			return err
 		}
 
-		jsonWebToken := jwt.New(&h.app.Config.App)
-		token, expiresIn, err := jsonWebToken.Generate(&jwt.JWTPayload{
-			UID:       user.ID.String(),
-			Secret:    h.app.Config.App.JWTSecret,
-			ExpiresAt: "1", // 1 day
-		})
+		var expiresAt time.Time
+		token, expiresAt, err = h.verificationToken(user.ID)
 		if err != nil {
 			return err
 		}
 
+		now := time.Now()
+
 		userVerifyAccount.ID = user.ID
-		userVerifyAccount.Token = token
-		userVerifyAccount.ExpiresAt = time.Unix(expiresIn, 0)
+		userVerifyAccount.Token = lib.HashToken(token)
+		userVerifyAccount.ExpiresAt = expiresAt
+		userVerifyAccount.SentCount = 1
+		userVerifyAccount.SentWindowStartedAt = now
+		userVerifyAccount.LastSentAt = now
 
 		return h.app.Repositories.UserVerifyAccount.InsertExec(tx, userVerifyAccount)
 	})
@@ -85,24 +99,7 @@ func (h *authHandler) SignUp(c *gin.Context) {
 		return
 	}
 
-	link := fmt.Sprintf("%s/verify?token=%s", h.app.Config.App.ClientURL, userVerifyAccount.Token)
-
-	emailForm := struct {
-		Fullname string
-		Link     string
-		AppName  string
-	}{
-		Fullname: strings.Join([]string{dto.FirstName, *dto.LastName}, " "),
-		Link:     link,
-		AppName:  h.app.Config.App.Name,
-	}
-
-	_, err = h.app.Services.Email.SendEmail(services.SendEmailParams{
-		Subject:      "Verify your email address",
-		To:           dto.Email,
-		Data:         emailForm,
-		HtmlTemplate: "templates/emails/registration.html",
-	})
+	err = h.sendVerificationEmail(user, token)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
@@ -126,61 +123,344 @@ func (h *authHandler) SignIn(c *gin.Context) {
 		return
 	}
 
-	user, err := h.app.Repositories.User.GetByEmail(dto.Email)
+	policies := h.signInLockoutPolicies(c, dto.Email)
+
+	retryAfter, locked, err := h.lockoutRetryAfter(policies)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	hash := argon2.New()
-	match, err := hash.Compare(*user.Password, dto.Password)
+	if retryAfter > 0 {
+		tooManyAttempts(c, retryAfter, locked, "Too many failed sign in attempts, please try again later")
+		return
+	}
+
+	user, err := h.app.Repositories.User.GetByEmail(dto.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			h.signInFailed(c, policies, nil)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	if user.Password == nil {
+		h.signInFailed(c, policies, user)
+		return
+	}
+
+	match, err := h.app.Password.Compare(dto.Password, *user.Password)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
 	if !match {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid email or password"})
+		h.signInFailed(c, policies, user)
 		return
 	}
 
-	jsonWebToken := jwt.New(&h.app.Config.App)
-	token, expiresIn, err := jsonWebToken.Generate(&jwt.JWTPayload{
-		UID:       user.ID.String(),
-		Secret:    h.app.Config.App.JWTSecret,
-		ExpiresAt: "1", // 1 day
+	if h.app.Password.NeedsRehash(*user.Password) {
+		h.rehashPassword(user, dto.Password)
+	}
+
+	err = h.app.Repositories.LoginAttempt.Delete(lockout.EmailKey(dto.Email))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	// Account status is only revealed once the password is proven correct.
+	h.completeSignIn(c, user)
+}
+
+func (h *authHandler) VerifyTwoFactor(c *gin.Context) {
+	var dto dto.AuthVerifyTwoFactor
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			c.JSON(http.StatusBadRequest, lib.WrapValidationError(e.MessageRecord))
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	if (dto.Code == nil) == (dto.RecoveryCode == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Provide either code or recovery_code"})
+		return
+	}
+
+	claims, err := h.app.JWT.Verify(dto.ChallengeToken)
+	if err != nil || claims.Purpose != jwt.PurposeTwoFactor {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid challenge token"})
+		return
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil || claims.IssuedAt == nil || claims.ExpiresAt == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid challenge token"})
+		return
+	}
+
+	consumed, err := h.app.Revocations.IsRevoked(claims.IssuedAt.Time, revocation.JTIKey(claims.ID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	if consumed {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid challenge token"})
+		return
+	}
+
+	user, err := h.app.Repositories.User.Get(userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid challenge token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	policies := h.twoFactorLockoutPolicies(claims.ID, user.Email)
+
+	retryAfter, locked, err := h.lockoutRetryAfter(policies)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	if retryAfter > 0 {
+		tooManyAttempts(c, retryAfter, locked, "Too many failed two-factor attempts, please try again later")
+		return
+	}
+
+	// The account may have been blocked since the challenge was issued.
+	if user.BlockedAt != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":    constant.ErrCodeAccountBlocked,
+			"message": "Your account has been blocked",
+		})
+		return
+	}
+
+	twoFactor, err := h.app.Repositories.UserTwoFactor.Get(user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid challenge token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	if twoFactor.ConfirmedAt == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid challenge token"})
+		return
+	}
+
+	var step int64
+	if dto.Code != nil {
+		step, err = totp.New().Validate(twoFactor.Secret, *dto.Code)
+		if err != nil {
+			switch {
+			case errors.Is(err, totp.ErrInvalidCode):
+				h.twoFactorFailed(c, policies, user, "Invalid two-factor code")
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			}
+			return
+		}
+	}
+
+	reason := "two-factor challenge consumed"
+	challenge := &models.TokenRevocation{
+		Key:           revocation.JTIKey(claims.ID),
+		RevokedBefore: time.Now(),
+		ExpiresAt:     claims.ExpiresAt.Add(h.app.Config.App.JWTLeeway),
+		Reason:        &reason,
+	}
+
+	// The challenge is consumed along with the code, so neither can be
+	// replayed and a replayed challenge does not burn a recovery code.
+	err = lib.WithTransaction(h.app.Repositories.TokenRevocation.DB, func(tx *sql.Tx) error {
+		if dto.Code != nil {
+			// A code is accepted once, even though it stays valid for its whole period.
+			err := h.app.Repositories.UserTwoFactor.UseStepExec(tx, twoFactor.ID, step)
+			if err != nil {
+				return err
+			}
+		} else {
+			code := lib.HashToken(totp.NormalizeRecoveryCode(*dto.RecoveryCode))
+
+			err := h.app.Repositories.UserRecoveryCode.UseExec(tx, user.ID, code)
+			if err != nil {
+				return err
+			}
+		}
+
+		return h.app.Repositories.TokenRevocation.ConsumeExec(tx, challenge)
 	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrEditConflict):
+			h.twoFactorFailed(c, policies, user, "Invalid two-factor code")
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			h.twoFactorFailed(c, policies, user, "Invalid recovery code")
+		case errors.Is(err, repositories.ErrInsertDuplicate):
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid challenge token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	syncRevocations(h.app)
+
+	err = h.app.Repositories.LoginAttempt.Delete(lockout.ChallengeKey(claims.ID), lockout.EmailKey(user.Email))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	session := &models.Session{
-		Base: models.Base{
-			ID: uuid.Must(uuid.NewV7()),
-		},
-		UserID:    user.ID,
-		Token:     token,
-		ExpiresAt: time.Unix(expiresIn, 0),
-		IPAddress: c.ClientIP(),
-		UserAgent: c.Request.UserAgent(),
+	h.startSession(c, user, "Sign in successfully")
+}
+
+// Refresh exchanges a refresh token for a new session. In cookie mode the
+// refresh token cookie is used when present, and the CSRF token is required
+// along with it.
+func (h *authHandler) Refresh(c *gin.Context) {
+	refreshToken, fromCookie := h.refreshTokenCookie(c)
+	if fromCookie {
+		if err := csrf.Verify(c); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"message": fmt.Sprintf("Forbidden, %s", err.Error())})
+			return
+		}
+	} else {
+		var dto dto.AuthRefresh
+
+		if err := lib.ValidateRequestBody(c, &dto); err != nil {
+			switch e := err.(type) {
+			case *lib.ErrValidationFailed:
+				c.JSON(http.StatusBadRequest, lib.WrapValidationError(e.MessageRecord))
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			}
+			return
+		}
+
+		refreshToken = dto.RefreshToken
 	}
 
-	err = h.app.Repositories.Session.Insert(session)
+	current, err := h.app.Repositories.Session.GetByRefreshToken(lib.HashToken(refreshToken))
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid refresh token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	// A refresh token can only be exchanged once. Seeing it again means it
+	// has leaked, so every session descending from the same sign-in is revoked.
+	if current.RotatedAt != nil {
+		h.revokeSessionFamily(c, current.FamilyID)
+		return
+	}
+
+	now := time.Now()
+
+	if current.RefreshExpiresAt.Before(now) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Refresh token expired"})
+		return
+	}
+
+	if !current.LastSeenAt.After(h.app.Config.Auth.SessionIdleSince(now)) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Session expired due to inactivity, please sign in again"})
+		return
+	}
+
+	maxLifetime := h.app.Config.Auth.SessionMaxLifetime
+	if maxLifetime > 0 && !now.Before(current.SignedInAt.Add(maxLifetime)) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Session expired, please sign in again"})
+		return
+	}
+
+	// Blocked or deleted users cannot keep their sessions alive.
+	user, err := h.app.Repositories.User.GetByID(current.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid refresh token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	session, tokens, err := h.newSession(c, user, current.FamilyID, current.SignedInAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	// The access token of the rotated session is revoked along with it, so
+	// only the tokens just issued stay usable.
+	reason := "session rotated"
+	rotated := &models.TokenRevocation{
+		Key:           revocation.SessionKey(current.ID.String()),
+		RevokedBefore: now,
+		ExpiresAt:     now.Add(h.app.Config.Auth.AccessTokenExpiresIn + h.app.Config.App.JWTLeeway),
+		Reason:        &reason,
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.Session.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.Session.RotateExec(tx, current.ID)
+		if err != nil {
+			return err
+		}
+
+		err = h.app.Repositories.TokenRevocation.InsertExec(tx, rotated)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.Session.InsertExec(tx, session)
+	})
 	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrEditConflict):
+			h.revokeSessionFamily(c, current.FamilyID)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	syncRevocations(h.app)
+
+	data := gin.H{
+		"uid": session.UserID.String(),
+	}
+
+	if err := h.issueTokens(c, data, session, tokens); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, types.ResponseSingleData[any]{
-		Message: "Sign in successfully",
-		Data: gin.H{
-			"uid":          user.ID.String(),
-			"email":        user.Email,
-			"display_name": strings.Join([]string{user.FirstName, *user.LastName}, " "),
-			"is_admin":     user.RoleID.String() == constant.RoleAdmin,
-			"access_token": token,
-		},
+		Message: "Refresh session successfully",
+		Data:    data,
 	})
 }
 
@@ -197,23 +477,31 @@ func (h *authHandler) VerifyRegistration(c *gin.Context) {
 		return
 	}
 
-	jsonWebToken := jwt.New(&h.app.Config.App)
-	claims, err := jsonWebToken.Verify(dto.Token)
-	if err != nil {
+	claims, err := h.app.JWT.Verify(dto.Token)
+	if err != nil || claims.Purpose != jwt.PurposeVerifyAccount {
 		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid token"})
 		return
 	}
 
-	userID := uuid.Must(uuid.Parse(claims.UID))
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid token"})
+		return
+	}
 
-	userVerifyAccount, err := h.app.Repositories.UserVerifyAccount.Get(userID, dto.Token)
+	userVerifyAccount, err := h.app.Repositories.UserVerifyAccount.Get(userID, lib.HashToken(dto.Token))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
 		return
 	}
 
 	if userVerifyAccount.ExpiresAt.Before(time.Now()) {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Token expired"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Token expired, please request a new verification email"})
 		return
 	}
 
@@ -236,47 +524,742 @@ func (h *authHandler) VerifyRegistration(c *gin.Context) {
 	})
 }
 
-func (h *authHandler) VerifySession(c *gin.Context) {
-	uid, err := lib.ContextGetUID(c)
+func (h *authHandler) ResendVerification(c *gin.Context) {
+	var dto dto.AuthResendVerification
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			c.JSON(http.StatusBadRequest, lib.WrapValidationError(e.MessageRecord))
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	// The response never reveals whether the email belongs to an account.
+	response := gin.H{
+		"message": "If the email is awaiting verification, a new verification link has been sent",
+	}
+
+	user, err := h.app.Repositories.User.GetInactiveByEmail(dto.Email)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusOK, response)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
 		return
 	}
 
-	user, err := h.app.Repositories.User.Get(uid)
+	token, expiresAt, err := h.verificationToken(user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, types.ResponseSingleData[*models.User]{
-		Message: "Verify session successfully",
-		Data:    user,
-	})
-}
+	err = lib.WithTransaction(h.app.Repositories.UserVerifyAccount.DB, func(tx *sql.Tx) error {
+		now := time.Now()
 
-func (h *authHandler) SignOut(c *gin.Context) {
-	jwt := jwt.New(&h.app.Config.App)
+		userVerifyAccount, err := h.app.Repositories.UserVerifyAccount.GetByIDForUpdate(tx, user.ID)
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			userVerifyAccount = &models.UserVerifyAccount{ID: user.ID, SentWindowStartedAt: now}
+		case err != nil:
+			return err
+		default:
+			if now.Sub(userVerifyAccount.SentWindowStartedAt) >= emailLimitWindow {
+				userVerifyAccount.SentCount = 0
+				userVerifyAccount.SentWindowStartedAt = now
+			}
+
+			if now.Sub(userVerifyAccount.LastSentAt) < h.app.Config.Auth.VerifyResendCooldown {
+				return errEmailLimited
+			}
+
+			if userVerifyAccount.SentCount >= h.app.Config.Auth.VerifyResendDailyLimit {
+				return errEmailLimited
+			}
+		}
+
+		userVerifyAccount.Token = lib.HashToken(token)
+		userVerifyAccount.ExpiresAt = expiresAt
+		userVerifyAccount.SentCount++
+		userVerifyAccount.LastSentAt = now
 
-	extractToken, err := jwt.ExtractToken(c)
+		return h.app.Repositories.UserVerifyAccount.UpsertExec(tx, userVerifyAccount)
+	})
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": fmt.Sprintf("Unauthorized, %s", err.Error())})
+		switch {
+		// Limited requests get the same response as unknown emails, a 429
+		// would tell that the email belongs to an unverified account.
+		case errors.Is(err, errEmailLimited):
+			c.JSON(http.StatusOK, response)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
 		return
 	}
 
-	uid, err := lib.ContextGetUID(c)
+	err = h.sendVerificationEmail(user, token)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *authHandler) ForgotPassword(c *gin.Context) {
+	var dto dto.AuthForgotPassword
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			c.JSON(http.StatusBadRequest, lib.WrapValidationError(e.MessageRecord))
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
 		return
 	}
 
-	err = h.app.Repositories.Session.Delete(uid, extractToken)
+	// The response never reveals whether the email belongs to an account.
+	response := gin.H{
+		"message": "If the email is registered, a password reset link has been sent",
+	}
+
+	user, err := h.app.Repositories.User.GetByEmail(dto.Email)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusOK, response)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Sign out successfully",
-	})
+	if user.ActiveAt == nil || user.BlockedAt != nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	token, err := lib.RandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	expiresIn := h.app.Config.Auth.ResetPasswordExpiresIn
+
+	err = lib.WithTransaction(h.app.Repositories.UserResetPassword.DB, func(tx *sql.Tx) error {
+		now := time.Now()
+
+		resetPassword, err := h.app.Repositories.UserResetPassword.GetByIDForUpdate(tx, user.ID)
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			resetPassword = &models.UserResetPassword{ID: user.ID, SentWindowStartedAt: now}
+		case err != nil:
+			return err
+		default:
+			if now.Sub(resetPassword.SentWindowStartedAt) >= emailLimitWindow {
+				resetPassword.SentCount = 0
+				resetPassword.SentWindowStartedAt = now
+			}
+
+			if now.Sub(resetPassword.LastSentAt) < h.app.Config.Auth.ResetPasswordCooldown {
+				return errEmailLimited
+			}
+
+			if resetPassword.SentCount >= h.app.Config.Auth.ResetPasswordDailyLimit {
+				return errEmailLimited
+			}
+		}
+
+		resetPassword.Token = lib.HashToken(token)
+		resetPassword.ExpiresAt = now.Add(expiresIn)
+		resetPassword.SentCount++
+		resetPassword.LastSentAt = now
+
+		return h.app.Repositories.UserResetPassword.UpsertExec(tx, resetPassword)
+	})
+	if err != nil {
+		switch {
+		// Limited requests get the same response as unknown emails, so the
+		// limit cannot be used to tell whether an email is registered.
+		case errors.Is(err, errEmailLimited):
+			c.JSON(http.StatusOK, response)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", h.app.Config.App.ClientURL, token)
+
+	emailForm := struct {
+		Fullname  string
+		Link      string
+		AppName   string
+		ExpiresIn string
+	}{
+		Fullname:  user.FullName(),
+		Link:      link,
+		AppName:   h.app.Config.App.Name,
+		ExpiresIn: fmt.Sprintf("%d minutes", int(expiresIn.Minutes())),
+	}
+
+	_, err = h.app.Services.Email.SendEmail(services.SendEmailParams{
+		Subject:      "Reset your password",
+		To:           user.Email,
+		Data:         emailForm,
+		HtmlTemplate: "templates/emails/reset-password.html",
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *authHandler) ResetPassword(c *gin.Context) {
+	var dto dto.AuthResetPassword
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			c.JSON(http.StatusBadRequest, lib.WrapValidationError(e.MessageRecord))
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	resetPassword, err := h.app.Repositories.UserResetPassword.GetByToken(lib.HashToken(dto.Token))
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	if resetPassword.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Token expired"})
+		return
+	}
+
+	password, err := h.app.Password.Generate(dto.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.User.DB, func(tx *sql.Tx) error {
+		// Deleting the token first makes a concurrent reset with the same link fail.
+		err := h.app.Repositories.UserResetPassword.DeleteExec(tx, resetPassword.ID)
+		if err != nil {
+			return err
+		}
+
+		err = h.app.Repositories.User.UpdatePasswordExec(tx, resetPassword.ID, password)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.Session.DeleteByUserIDExec(tx, resetPassword.ID)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	syncRevocations(h.app)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reset password successfully",
+	})
+}
+
+func (h *authHandler) VerifySession(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	user, err := h.app.Repositories.User.Get(uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[*models.User]{
+		Message: "Verify session successfully",
+		Data:    user,
+	})
+}
+
+// SignOut revokes the session the request was authorized with, wherever its
+// token was read from.
+func (h *authHandler) SignOut(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	sessionID, err := lib.ContextGetSessionID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	// A session that is already gone has nothing left to sign out of.
+	err = h.app.Repositories.Session.DeleteBySessionID(uid, sessionID)
+	if err != nil && !errors.Is(err, repositories.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	syncRevocations(h.app)
+	h.clearTokens(c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sign out successfully",
+	})
+}
+
+// SignOutAll revokes every session of the caller except the current one.
+func (h *authHandler) SignOutAll(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	sessionID, err := lib.ContextGetSessionID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	err = h.app.Repositories.Session.DeleteOthers(uid, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	syncRevocations(h.app)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Signed out of all other sessions successfully",
+	})
+}
+
+// signInLockoutPolicies returns the failed attempt policy for each key a sign
+// in is tracked under: the account and the client address.
+func (h *authHandler) signInLockoutPolicies(c *gin.Context, email string) map[string]*lockout.Policy {
+	cfg := h.app.Config.Auth
+
+	ipPolicy := lockout.New(cfg.LockoutIPMaxAttempts, cfg.LockoutWindow, cfg.LockoutDuration)
+	// Many users can share an address behind NAT, so delays start later.
+	ipPolicy.FreeAttempts = cfg.LockoutIPMaxAttempts / 2
+
+	return map[string]*lockout.Policy{
+		lockout.EmailKey(email):     h.accountLockoutPolicy(),
+		lockout.IPKey(c.ClientIP()): ipPolicy,
+	}
+}
+
+// twoFactorLockoutPolicies returns the failed attempt policy for each key a
+// two-factor verification is tracked under: the challenge and the account, so
+// signing in again for a fresh challenge does not reset the count.
+func (h *authHandler) twoFactorLockoutPolicies(jti string, email string) map[string]*lockout.Policy {
+	cfg := h.app.Config.Auth
+
+	// A locked challenge is never unlocked before it expires.
+	challengePolicy := lockout.New(cfg.LockoutMaxAttempts, cfg.TwoFactorChallengeExpiresIn, cfg.TwoFactorChallengeExpiresIn)
+
+	return map[string]*lockout.Policy{
+		lockout.ChallengeKey(jti): challengePolicy,
+		lockout.EmailKey(email):   h.accountLockoutPolicy(),
+	}
+}
+
+// accountLockoutPolicy is the failed attempt policy of the email key, shared
+// by every check of the credentials of an account.
+func (h *authHandler) accountLockoutPolicy() *lockout.Policy {
+	cfg := h.app.Config.Auth
+
+	return lockout.New(cfg.LockoutMaxAttempts, cfg.LockoutWindow, cfg.LockoutDuration)
+}
+
+// lockoutRetryAfter returns the longest wait imposed by any of the keys and
+// whether it comes from a lockout.
+func (h *authHandler) lockoutRetryAfter(policies map[string]*lockout.Policy) (time.Duration, bool, error) {
+	keys := make([]string, 0, len(policies))
+	for key := range policies {
+		keys = append(keys, key)
+	}
+
+	attempts, err := h.app.Repositories.LoginAttempt.GetByKeys(keys...)
+	if err != nil {
+		return 0, false, err
+	}
+
+	now := time.Now()
+
+	var retryAfter time.Duration
+	var locked bool
+	for _, attempt := range attempts {
+		wait, isLocked := policies[attempt.Key].RetryAfter(lockout.State{
+			Failures:     attempt.Failures,
+			LastFailedAt: attempt.LastFailedAt,
+			LockedUntil:  attempt.LockedUntil,
+		}, now)
+
+		if wait > retryAfter {
+			retryAfter = wait
+			locked = isLocked
+		}
+	}
+
+	return retryAfter, locked, nil
+}
+
+// tooManyAttempts responds to a caller that has to wait retryAfter before
+// trying again.
+func tooManyAttempts(c *gin.Context, retryAfter time.Duration, locked bool, message string) {
+	code := constant.ErrCodeTooManyAttempts
+	if locked {
+		code = constant.ErrCodeAccountLocked
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"code":    code,
+		"message": message,
+	})
+}
+
+// signInFailed records the failure and responds with the generic sign in
+// error.
+func (h *authHandler) signInFailed(c *gin.Context, policies map[string]*lockout.Policy, user *models.User) {
+	if err := h.recordFailure(policies, user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid email or password"})
+}
+
+// twoFactorFailed records the failure and responds with message.
+func (h *authHandler) twoFactorFailed(c *gin.Context, policies map[string]*lockout.Policy, user *models.User, message string) {
+	if err := h.recordFailure(policies, user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusUnauthorized, gin.H{"message": message})
+}
+
+// recordFailure counts the failure against every key and locks the keys that
+// reached their limit. The account owner is notified by email when their
+// account becomes locked.
+func (h *authHandler) recordFailure(policies map[string]*lockout.Policy, user *models.User) error {
+	now := time.Now()
+
+	for key, policy := range policies {
+		attempt, err := h.app.Repositories.LoginAttempt.RecordFailure(key, now, policy.WindowStart(now))
+		if err != nil {
+			return err
+		}
+
+		if !policy.ShouldLock(attempt.Failures) {
+			continue
+		}
+
+		locked, err := h.app.Repositories.LoginAttempt.Lock(key, policy.LockedUntil(now), now)
+		if err != nil {
+			return err
+		}
+
+		if !locked {
+			continue
+		}
+
+		h.app.Logger.Warn("sign in locked after repeated failures", "key", key, "locked_for", policy.Duration.String())
+
+		if user != nil && key == lockout.EmailKey(user.Email) {
+			// The lock is already in place, a failed notification must not
+			// change the response.
+			if err := h.sendLockoutEmail(user, policy.Duration); err != nil {
+				h.app.Logger.Error("failed to send lockout email", "error", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (h *authHandler) sendLockoutEmail(user *models.User, lockedFor time.Duration) error {
+	link := fmt.Sprintf("%s/forgot-password", h.app.Config.App.ClientURL)
+
+	emailForm := struct {
+		Fullname  string
+		Link      string
+		AppName   string
+		LockedFor string
+	}{
+		Fullname:  user.FullName(),
+		Link:      link,
+		AppName:   h.app.Config.App.Name,
+		LockedFor: fmt.Sprintf("%d minutes", int(lockedFor.Minutes())),
+	}
+
+	_, err := h.app.Services.Email.SendEmail(services.SendEmailParams{
+		Subject:      "Your account has been temporarily locked",
+		To:           user.Email,
+		Data:         emailForm,
+		HtmlTemplate: "templates/emails/account-locked.html",
+	})
+
+	return err
+}
+
+// verificationToken signs the token embedded in the verification link.
+func (h *authHandler) verificationToken(userID uuid.UUID) (string, time.Time, error) {
+	token, claims, err := h.app.JWT.Generate(&jwt.JWTPayload{
+		UID:       userID.String(),
+		ExpiresIn: h.app.Config.Auth.VerifyAccountExpiresIn,
+		Purpose:   jwt.PurposeVerifyAccount,
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, claims.ExpiresAt.Time, nil
+}
+
+func (h *authHandler) sendVerificationEmail(user *models.User, token string) error {
+	link := fmt.Sprintf("%s/verify?token=%s", h.app.Config.App.ClientURL, token)
+
+	emailForm := struct {
+		Fullname string
+		Link     string
+		AppName  string
+	}{
+		Fullname: user.FullName(),
+		Link:     link,
+		AppName:  h.app.Config.App.Name,
+	}
+
+	_, err := h.app.Services.Email.SendEmail(services.SendEmailParams{
+		Subject:      "Verify your email address",
+		To:           user.Email,
+		Data:         emailForm,
+		HtmlTemplate: "templates/emails/registration.html",
+	})
+
+	return err
+}
+
+// rehashPassword upgrades the stored hash of password to the current argon2
+// parameters. It is best effort, the old hash keeps working if it fails.
+func (h *authHandler) rehashPassword(user *models.User, password string) {
+	newHash, err := h.app.Password.Generate(password)
+	if err != nil {
+		h.app.Logger.Warn("failed to rehash password", "user_id", user.ID.String(), "error", err)
+		return
+	}
+
+	// A conflict means the password was changed since it was read, which
+	// leaves nothing to upgrade.
+	err = h.app.Repositories.User.RehashPassword(user.ID, *user.Password, newHash)
+	if err != nil && !errors.Is(err, repositories.ErrEditConflict) {
+		h.app.Logger.Warn("failed to rehash password", "user_id", user.ID.String(), "error", err)
+	}
+}
+
+// sessionTokens are the credentials handed to the client. The session only
+// keeps their digests.
+type sessionTokens struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// newSession signs a fresh access and refresh token pair for the user. An empty
+// familyID starts a new family signed in now, otherwise the session continues
+// familyID signed in at signedInAt. Neither token outlives the maximum session
+// lifetime.
+func (h *authHandler) newSession(c *gin.Context, user *models.User, familyID uuid.UUID, signedInAt time.Time) (*models.Session, sessionTokens, error) {
+	now := time.Now()
+
+	sessionID := uuid.Must(uuid.NewV7())
+	if familyID == uuid.Nil {
+		familyID = sessionID
+		signedInAt = now
+	}
+
+	accessExpiresIn := h.app.Config.Auth.AccessTokenExpiresIn
+	refreshExpiresAt := now.Add(h.app.Config.Auth.RefreshTokenExpiresIn)
+
+	if maxLifetime := h.app.Config.Auth.SessionMaxLifetime; maxLifetime > 0 {
+		endsAt := signedInAt.Add(maxLifetime)
+		accessExpiresIn = min(accessExpiresIn, endsAt.Sub(now))
+		if refreshExpiresAt.After(endsAt) {
+			refreshExpiresAt = endsAt
+		}
+	}
+
+	token, claims, err := h.app.JWT.Generate(&jwt.JWTPayload{
+		UID:       user.ID.String(),
+		SessionID: sessionID.String(),
+		Role:      user.RoleID.String(),
+		ExpiresIn: accessExpiresIn,
+	})
+	if err != nil {
+		return nil, sessionTokens{}, err
+	}
+
+	refreshToken, err := lib.RandomToken(32)
+	if err != nil {
+		return nil, sessionTokens{}, err
+	}
+
+	return &models.Session{
+		Base: models.Base{
+			ID: sessionID,
+		},
+		UserID:           user.ID,
+		FamilyID:         familyID,
+		Token:            lib.HashToken(token),
+		ExpiresAt:        claims.ExpiresAt.Time,
+		RefreshToken:     lib.HashToken(refreshToken),
+		RefreshExpiresAt: refreshExpiresAt,
+		IPAddress:        c.ClientIP(),
+		UserAgent:        c.Request.UserAgent(),
+		SignedInAt:       signedInAt,
+		LastSeenAt:       now,
+		LastIP:           c.ClientIP(),
+	}, sessionTokens{AccessToken: token, RefreshToken: refreshToken}, nil
+}
+
+// completeSignIn finishes a sign in once the user has proven who they are,
+// checking the account status and asking for a second factor when enabled.
+func (h *authHandler) completeSignIn(c *gin.Context, user *models.User) {
+	if user.BlockedAt != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":    constant.ErrCodeAccountBlocked,
+			"message": "Your account has been blocked",
+		})
+		return
+	}
+
+	if user.ActiveAt == nil && h.app.Config.Auth.RequireVerifiedAccount {
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":    constant.ErrCodeAccountNotVerified,
+			"message": "Please verify your email address before signing in",
+		})
+		return
+	}
+
+	twoFactor, err := h.app.Repositories.UserTwoFactor.Get(user.ID)
+	if err != nil && !errors.Is(err, repositories.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	// With two-factor enabled the password only earns a short-lived challenge
+	// token, which is exchanged for a session at /v1/auth/2fa/verify.
+	if twoFactor != nil && twoFactor.ConfirmedAt != nil {
+		challengeToken, claims, err := h.app.JWT.Generate(&jwt.JWTPayload{
+			UID:       user.ID.String(),
+			ExpiresIn: h.app.Config.Auth.TwoFactorChallengeExpiresIn,
+			Purpose:   jwt.PurposeTwoFactor,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, types.ResponseSingleData[any]{
+			Message: "Two-factor authentication required",
+			Data: gin.H{
+				"two_factor_required": true,
+				"challenge_token":     challengeToken,
+				"expires_at":          claims.ExpiresAt.Time,
+			},
+		})
+		return
+	}
+
+	h.startSession(c, user, "Sign in successfully")
+}
+
+// startSession opens a new session family for the user and responds with the
+// sign in payload.
+func (h *authHandler) startSession(c *gin.Context, user *models.User, message string) {
+	permissions, err := h.app.Repositories.RolePermission.ListNamesByUserID(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	session, tokens, err := h.newSession(c, user, uuid.Nil, time.Time{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	err = h.app.Repositories.Session.Insert(session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	// Any role able to manage users or roles counts as an admin, whatever it
+	// is called. Clients should prefer checking permissions.
+	data := gin.H{
+		"uid":          user.ID.String(),
+		"email":        user.Email,
+		"display_name": user.FullName(),
+		"is_admin":     lib.Contains(permissions, constant.PermissionUsersWrite) || lib.Contains(permissions, constant.PermissionRolesWrite),
+		"permissions":  permissions,
+	}
+
+	if err := h.issueTokens(c, data, session, tokens); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[any]{
+		Message: message,
+		Data:    data,
+	})
+}
+
+func (h *authHandler) revokeSessionFamily(c *gin.Context, familyID uuid.UUID) {
+	err := h.app.Repositories.Session.DeleteByFamilyID(familyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	syncRevocations(h.app)
+
+	h.app.Logger.Warn("refresh token reuse detected, session family revoked", "family_id", familyID.String())
+	h.clearTokens(c)
+
+	c.JSON(http.StatusUnauthorized, gin.H{"message": "Refresh token has already been used, please sign in again"})
 }
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"gintama/internal/dto"
+	"gintama/internal/lib"
+	"gintama/internal/lib/lockout"
+	"gintama/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ChangePassword replaces the password of the caller once the current one is
+// proven, and signs out every other session.
+func (h *authHandler) ChangePassword(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	sessionID, err := lib.ContextGetSessionID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	var dto dto.AuthChangePassword
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			c.JSON(http.StatusBadRequest, lib.WrapValidationError(e.MessageRecord))
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	hasPassword, ok := h.checkPassword(c, uid, dto.CurrentPassword)
+	if !ok {
+		return
+	}
+
+	if !hasPassword {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Your account has no password, set one with forgot password"})
+		return
+	}
+
+	password, err := h.app.Password.Generate(dto.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.User.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.User.UpdatePasswordExec(tx, uid, password)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.Session.DeleteOthersExec(tx, uid, sessionID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	syncRevocations(h.app)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password changed successfully",
+	})
+}
+
+// DeleteAccount soft deletes the caller and signs them out everywhere, as an
+// admin deleting the user would. Accounts with a password must confirm it.
+func (h *authHandler) DeleteAccount(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	var dto dto.AuthDeleteAccount
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			c.JSON(http.StatusBadRequest, lib.WrapValidationError(e.MessageRecord))
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	if _, ok := h.checkPassword(c, uid, dto.Password); !ok {
+		return
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.User.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.User.SoftDeleteExec(tx, uid)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.Session.DeleteByUserIDExec(tx, uid)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	syncRevocations(h.app)
+	h.clearTokens(c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Account deleted successfully",
+	})
+}
+
+// checkPassword compares password with the one of the user. It reports
+// whether the user has a password, a user without one passes the check. It
+// responds and returns false when the password does not match. Failures count
+// towards the sign in lockout of the account, so the check cannot be used to
+// guess the password of a stolen session.
+func (h *authHandler) checkPassword(c *gin.Context, uid uuid.UUID, password string) (bool, bool) {
+	user, err := h.app.Repositories.User.Get(uid)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return false, false
+	}
+
+	hashed, err := h.app.Repositories.User.GetPassword(uid)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return false, false
+	}
+
+	if hashed == nil {
+		return false, true
+	}
+
+	key := lockout.EmailKey(user.Email)
+	policies := map[string]*lockout.Policy{key: h.accountLockoutPolicy()}
+
+	retryAfter, locked, err := h.lockoutRetryAfter(policies)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return true, false
+	}
+
+	if retryAfter > 0 {
+		tooManyAttempts(c, retryAfter, locked, "Too many failed password attempts, please try again later")
+		return true, false
+	}
+
+	match, err := h.app.Password.Compare(password, *hashed)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return true, false
+	}
+
+	if !match {
+		if err := h.recordFailure(policies, user); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return true, false
+		}
+
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Password is incorrect"})
+		return true, false
+	}
+
+	err = h.app.Repositories.LoginAttempt.Delete(key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return true, false
+	}
+
+	return true, true
+}
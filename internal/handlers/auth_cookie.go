@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"gintama/internal/lib/csrf"
+	"gintama/internal/lib/jwt"
+	"gintama/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// refreshCookieName is only sent to the refresh route, so the refresh
+	// token never travels with ordinary requests.
+	refreshCookieName = "refresh_token"
+	refreshCookiePath = "/v1/auth/refresh"
+)
+
+// issueTokens hands the session tokens to the client. In cookie mode they are
+// set as HttpOnly cookies and the response carries the CSRF token instead,
+// otherwise they are added to data.
+func (h *authHandler) issueTokens(c *gin.Context, data gin.H, session *models.Session, tokens sessionTokens) error {
+	if !h.app.Config.Auth.SessionCookie {
+		data["access_token"] = tokens.AccessToken
+		data["refresh_token"] = tokens.RefreshToken
+		return nil
+	}
+
+	csrfToken, err := csrf.Generate()
+	if err != nil {
+		return err
+	}
+
+	accessCookieName := jwt.DefaultExtractOptions().CookieName
+
+	h.setCookie(c, accessCookieName, tokens.AccessToken, "/", session.ExpiresAt, true)
+	h.setCookie(c, refreshCookieName, tokens.RefreshToken, refreshCookiePath, session.RefreshExpiresAt, true)
+	// The CSRF cookie is read by scripts of the client to fill in the header,
+	// and lives as long as the refresh token that renews it.
+	h.setCookie(c, csrf.CookieName, csrfToken, "/", session.RefreshExpiresAt, false)
+
+	data["csrf_token"] = csrfToken
+	data["expires_at"] = session.ExpiresAt
+
+	return nil
+}
+
+// clearTokens removes the session cookies from the client.
+func (h *authHandler) clearTokens(c *gin.Context) {
+	if !h.app.Config.Auth.SessionCookie {
+		return
+	}
+
+	expired := time.Unix(0, 0)
+
+	h.setCookie(c, jwt.DefaultExtractOptions().CookieName, "", "/", expired, true)
+	h.setCookie(c, refreshCookieName, "", refreshCookiePath, expired, true)
+	h.setCookie(c, csrf.CookieName, "", "/", expired, false)
+}
+
+// refreshTokenCookie returns the refresh token cookie, if cookie mode is on and
+// the client sent one.
+func (h *authHandler) refreshTokenCookie(c *gin.Context) (string, bool) {
+	if !h.app.Config.Auth.SessionCookie {
+		return "", false
+	}
+
+	refreshToken, _ := c.Cookie(refreshCookieName)
+	return refreshToken, refreshToken != ""
+}
+
+func (h *authHandler) setCookie(c *gin.Context, name, value, path string, expiresAt time.Time, httpOnly bool) {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   h.app.Config.Auth.CookieDomain,
+		Expires:  expiresAt,
+		Secure:   true,
+		HttpOnly: httpOnly,
+		SameSite: cookieSameSite(h.app.Config.Auth.CookieSameSite),
+	}
+
+	if value == "" {
+		cookie.MaxAge = -1
+	}
+
+	http.SetCookie(c.Writer, cookie)
+}
+
+func cookieSameSite(mode string) http.SameSite {
+	switch mode {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"gintama/internal/dto"
+	"gintama/internal/lib"
+	"gintama/internal/lib/constant"
+	"gintama/internal/lib/oidc"
+	"gintama/internal/models"
+	"gintama/internal/repositories"
+	"gintama/internal/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var errIdentityNotLinked = errors.New("identity is not linked to the account")
+
+// OIDCAuthorize starts a provider sign in. The PKCE verifier and nonce stay
+// on the server, keyed by the state the provider echoes back to the client.
+func (h *authHandler) OIDCAuthorize(c *gin.Context) {
+	provider, ok := h.oidcProvider(c)
+	if !ok {
+		return
+	}
+
+	state, err := lib.RandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	codeVerifier, err := oidc.RandomString(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), state, nonce, oidc.CodeChallenge(codeVerifier))
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"message": "Could not reach the identity provider", "error": err.Error()})
+		return
+	}
+
+	oidcState := &models.OIDCState{
+		ID:           lib.HashToken(state),
+		Provider:     provider.Name(),
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(h.app.Config.OIDC.StateExpiresIn),
+	}
+
+	err = h.app.Repositories.OIDCState.Insert(oidcState)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[any]{
+		Message: "Redirect to the identity provider",
+		Data: gin.H{
+			"url":        authURL,
+			"state":      state,
+			"expires_at": oidcState.ExpiresAt,
+		},
+	})
+}
+
+// OIDCCallback completes a provider sign in with the code and state the
+// provider redirected back with, then signs in the linked user.
+func (h *authHandler) OIDCCallback(c *gin.Context) {
+	provider, ok := h.oidcProvider(c)
+	if !ok {
+		return
+	}
+
+	var dto dto.AuthOIDCCallback
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			c.JSON(http.StatusBadRequest, lib.WrapValidationError(e.MessageRecord))
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	state, err := h.app.Repositories.OIDCState.Consume(lib.HashToken(dto.State), provider.Name())
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Sign in state is invalid or was already used"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	if time.Now().After(state.ExpiresAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Sign in state has expired, please try again"})
+		return
+	}
+
+	claims, err := provider.Exchange(c.Request.Context(), dto.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		switch {
+		case errors.Is(err, oidc.ErrDiscoveryFailed):
+			c.JSON(http.StatusBadGateway, gin.H{"message": "Could not reach the identity provider", "error": err.Error()})
+		default:
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Identity provider sign in failed", "error": err.Error()})
+		}
+		return
+	}
+
+	user, err := h.oidcUser(provider.Name(), claims)
+	if err != nil {
+		switch {
+		case errors.Is(err, errIdentityNotLinked):
+			c.JSON(http.StatusConflict, gin.H{
+				"code":    constant.ErrCodeIdentityNotLinked,
+				"message": "An account with this email already exists, sign in with your password to continue",
+			})
+		case errors.Is(err, repositories.ErrInsertDuplicate):
+			c.JSON(http.StatusConflict, gin.H{"message": "This identity is already linked to an account"})
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "The linked account no longer exists"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	h.completeSignIn(c, user)
+}
+
+func (h *authHandler) oidcProvider(c *gin.Context) (oidc.Provider, bool) {
+	provider, ok := h.app.OIDC[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"message": "identity provider not found"})
+		return nil, false
+	}
+
+	return provider, true
+}
+
+// oidcUser resolves the user behind a verified provider identity. Unknown
+// identities are linked to an existing account only when both the provider
+// and the account have verified the email address, otherwise an account
+// created by someone else with the same email could be taken over. New
+// emails get an account without a password.
+func (h *authHandler) oidcUser(provider string, claims *oidc.Claims) (*models.User, error) {
+	identity, err := h.app.Repositories.UserIdentity.GetByProviderSubject(provider, claims.Subject)
+	if err == nil {
+		return h.app.Repositories.User.Get(identity.UserID)
+	}
+	if !errors.Is(err, repositories.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, errIdentityNotLinked
+	}
+
+	email := claims.Email
+
+	identity = &models.UserIdentity{
+		ID:       uuid.Must(uuid.NewV7()),
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    &email,
+	}
+
+	user, err := h.app.Repositories.User.GetByEmail(email)
+	switch {
+	case err == nil:
+		if user.ActiveAt == nil {
+			return nil, errIdentityNotLinked
+		}
+
+		identity.UserID = user.ID
+
+		err = h.app.Repositories.UserIdentity.Insert(identity)
+		if err != nil {
+			return nil, err
+		}
+
+		return user, nil
+	case !errors.Is(err, repositories.ErrRecordNotFound):
+		return nil, err
+	}
+
+	now := time.Now()
+
+	user = &models.User{
+		Base: models.Base{
+			ID: uuid.Must(uuid.NewV7()),
+		},
+		FirstName: oidcFirstName(claims, email),
+		Email:     email,
+		ActiveAt:  &now,
+		RoleID:    uuid.Must(uuid.Parse(constant.RoleUser)),
+	}
+
+	if claims.FamilyName != "" {
+		user.LastName = &claims.FamilyName
+	}
+
+	identity.UserID = user.ID
+
+	err = lib.WithTransaction(h.app.Repositories.User.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.User.InsertExec(tx, user)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.UserIdentity.InsertExec(tx, identity)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func oidcFirstName(claims *oidc.Claims, email string) string {
+	switch {
+	case claims.GivenName != "":
+		return claims.GivenName
+	case claims.Name != "":
+		return claims.Name
+	default:
+		return strings.SplitN(email, "@", 2)[0]
+	}
+}
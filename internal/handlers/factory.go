@@ -3,19 +3,35 @@ package handlers
 import "gintama/internal/app"
 
 type Handlers struct {
-	Health  healthHandler
-	Role    roleHandler
-	User    userHandler
-	Auth    authHandler
-	Session sessionHandler
+	Health          healthHandler
+	WellKnown       wellKnownHandler
+	Role            roleHandler
+	Permission      permissionHandler
+	User            userHandler
+	Auth            authHandler
+	TwoFactor       twoFactorHandler
+	Session         sessionHandler
+	Revocation      revocationHandler
+	APIKey          apiKeyHandler
+	Upload          uploadHandler
+	ResumableUpload resumableUploadHandler
+	File            fileHandler
 }
 
 func New(app *app.Application) Handlers {
 	return Handlers{
-		Health:  healthHandler{app: app},
-		Role:    roleHandler{app: app},
-		User:    userHandler{app: app},
-		Auth:    authHandler{app: app},
-		Session: sessionHandler{app: app},
+		Health:          healthHandler{app: app},
+		WellKnown:       wellKnownHandler{app: app},
+		Role:            roleHandler{app: app},
+		Permission:      permissionHandler{app: app},
+		User:            userHandler{app: app},
+		Auth:            authHandler{app: app},
+		TwoFactor:       twoFactorHandler{app: app},
+		Session:         sessionHandler{app: app},
+		Revocation:      revocationHandler{app: app},
+		APIKey:          apiKeyHandler{app: app},
+		Upload:          uploadHandler{app: app},
+		ResumableUpload: resumableUploadHandler{app: app},
+		File:            fileHandler{app: app},
 	}
 }
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"gintama/internal/app"
+	"gintama/internal/lib/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fileHandler struct {
+	app *app.Application
+}
+
+// Download serves the object of a signed URL made by the local storage
+// driver. The signature is the only authorization, like presigned S3 URLs.
+func (h *fileHandler) Download(c *gin.Context) {
+	local, ok := h.app.Storage.(*storage.Local)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Files are served by the storage provider"})
+		return
+	}
+
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	err := local.VerifySignedURL(key, c.Request.URL.Query())
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrURLExpired):
+			c.JSON(http.StatusForbidden, gin.H{"message": "Download link has expired"})
+		default:
+			c.JSON(http.StatusForbidden, gin.H{"message": "Download link is invalid"})
+		}
+		return
+	}
+
+	body, err := local.Get(c.Request.Context(), key)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+	defer body.Close()
+
+	content, ok := body.(io.ReadSeeker)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "stored file cannot be served"})
+		return
+	}
+
+	// Uploaded content must never run as a page of the API origin.
+	c.Header("Content-Security-Policy", "sandbox")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "private")
+
+	// Keys have no extension, so the type is sniffed as it was on upload.
+	http.ServeContent(c.Writer, c.Request, "", time.Time{}, content)
+}
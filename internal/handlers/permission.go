@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"gintama/internal/app"
+	"gintama/internal/dto"
+	"gintama/internal/lib"
+	"gintama/internal/models"
+	"gintama/internal/repositories"
+	"gintama/internal/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type permissionHandler struct {
+	app *app.Application
+}
+
+func (h *permissionHandler) Index(c *gin.Context) {
+	var dto dto.PermissionPagination
+
+	if err := lib.ValidateRequestQuery(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			c.JSON(http.StatusBadRequest, lib.WrapValidationError(e.MessageRecord))
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	opts := &repositories.QueryOptions{
+		Offset: dto.Offset,
+		Limit:  dto.Limit,
+	}
+
+	permissions, meta, err := h.app.Repositories.Permission.List(opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseMultiData[*models.Permission]{
+		Message: "list data has been retrieved successfully",
+		Data:    permissions,
+		Meta: gin.H{
+			"total": meta.Total,
+		},
+	})
+}
+
+func (h *permissionHandler) Show(c *gin.Context) {
+	permissionID, err := lib.ContextParamUUID(c, "permissionID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid permission id must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	permission, err := h.app.Repositories.Permission.Get(permissionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[*models.Permission]{
+		Message: "get data has been retrieved successfully",
+		Data:    permission,
+	})
+}
+
+func (h *permissionHandler) Create(c *gin.Context) {
+	var dto dto.PermissionCreate
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			c.JSON(http.StatusBadRequest, lib.WrapValidationError(e.MessageRecord))
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	permissionID, err := uuid.NewV7()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	permission := &models.Permission{
+		Base: models.Base{
+			ID: permissionID,
+		},
+		Name:        dto.Name,
+		Description: dto.Description,
+	}
+
+	err = h.app.Repositories.Permission.Insert(permission)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrInsertDuplicate):
+			c.JSON(http.StatusConflict, gin.H{"message": "permission name already exists"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[*models.Permission]{
+		Message: "data has been created successfully",
+		Data:    permission,
+	})
+}
+
+func (h *permissionHandler) Update(c *gin.Context) {
+	permissionID, err := lib.ContextParamUUID(c, "permissionID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid permission id must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var dto dto.PermissionUpdate
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			c.JSON(http.StatusBadRequest, lib.WrapValidationError(e.MessageRecord))
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	permission, err := h.app.Repositories.Permission.Get(permissionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	if dto.Name != "" {
+		permission.Name = dto.Name
+	}
+
+	if dto.Description != nil {
+		permission.Description = dto.Description
+	}
+
+	err = h.app.Repositories.Permission.Update(permissionID, permission)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrInsertDuplicate):
+			c.JSON(http.StatusConflict, gin.H{"message": "permission name already exists"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[*models.Permission]{
+		Message: "data has been updated successfully",
+		Data:    permission,
+	})
+}
+
+func (h *permissionHandler) Delete(c *gin.Context) {
+	permissionID, err := lib.ContextParamUUID(c, "permissionID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid permission id must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	err = h.app.Repositories.Permission.Delete(permissionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[*models.Permission]{
+		Message: "data has been deleted successfully",
+	})
+}
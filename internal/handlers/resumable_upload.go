@@ -0,0 +1,436 @@
+package handlers
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"database/sql"
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gintama/internal/app"
+	"gintama/internal/dto"
+	"gintama/internal/lib"
+	"gintama/internal/lib/storage"
+	"gintama/internal/models"
+	"gintama/internal/repositories"
+	"gintama/internal/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// uploadOffsetHeader carries the offset a chunk starts at in requests, and the
+// bytes received so far in responses, as in the tus protocol.
+const uploadOffsetHeader = "Upload-Offset"
+
+// resumableUploadHandler receives large files in chunks sent by separate
+// requests, each stored as a part of a multipart upload in storage. Progress
+// is kept in Postgres, so an interrupted upload resumes from the last chunk
+// received.
+type resumableUploadHandler struct {
+	app *app.Application
+}
+
+// Create starts an upload of size bytes. Checksum is the SHA-256 of the whole
+// file, checked once the last chunk is received.
+func (h *resumableUploadHandler) Create(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	var dto dto.ResumableUploadCreate
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			c.JSON(http.StatusBadRequest, lib.WrapValidationError(e.MessageRecord))
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	checksum := strings.ToLower(dto.Checksum)
+	if digest, err := hex.DecodeString(checksum); err != nil || len(digest) != sha256.Size {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "checksum must be a hex encoded SHA-256 digest"})
+		return
+	}
+
+	maxSize := h.app.Config.Storage.ResumableMaxSize
+	if dto.Size > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": fmt.Sprintf("File must not be larger than %d bytes", maxSize)})
+		return
+	}
+
+	hashState, err := sha256.New().(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	uploadID := uuid.Must(uuid.NewV7())
+
+	upload := &models.ResumableUpload{
+		ID:        uploadID,
+		UserID:    uid,
+		KeyFile:   fmt.Sprintf("uploads/%s/%s", uid, uploadID),
+		FileName:  cleanFileName(dto.FileName),
+		Size:      dto.Size,
+		Checksum:  checksum,
+		HashState: hashState,
+		ExpiresAt: time.Now().Add(h.app.Config.Storage.ResumableExpiresIn),
+	}
+
+	err = h.app.Repositories.ResumableUpload.Insert(upload)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.Header(uploadOffsetHeader, "0")
+	c.JSON(http.StatusOK, types.ResponseSingleData[*models.ResumableUpload]{
+		Message: "Resumable upload created successfully",
+		Data:    upload,
+	})
+}
+
+// Show returns the progress of an upload, the offset is where the next chunk
+// starts.
+func (h *resumableUploadHandler) Show(c *gin.Context) {
+	upload, ok := h.getUpload(c)
+	if !ok {
+		return
+	}
+
+	c.Header(uploadOffsetHeader, strconv.FormatInt(upload.Offset, 10))
+	c.JSON(http.StatusOK, types.ResponseSingleData[*models.ResumableUpload]{
+		Message: "get data has been retrieved successfully",
+		Data:    upload,
+	})
+}
+
+// Patch receives the chunk starting at the Upload-Offset header. Every chunk
+// but the last must hold at least storage.MinPartSize bytes. The last chunk
+// completes the upload and responds with the stored Upload.
+func (h *resumableUploadHandler) Patch(c *gin.Context) {
+	cfg := h.app.Config.Storage
+
+	offset, err := strconv.ParseInt(c.GetHeader(uploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Upload-Offset header must be the offset of the chunk in bytes"})
+		return
+	}
+
+	size := c.Request.ContentLength
+	if size < 0 {
+		c.JSON(http.StatusLengthRequired, gin.H{"message": "Content-Length header is required"})
+		return
+	}
+
+	if size > cfg.ChunkMaxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": fmt.Sprintf("Chunk must not be larger than %d bytes", cfg.ChunkMaxSize)})
+		return
+	}
+
+	upload, ok := h.getUpload(c)
+	if !ok {
+		return
+	}
+
+	if offset != upload.Offset {
+		c.Header(uploadOffsetHeader, strconv.FormatInt(upload.Offset, 10))
+		c.JSON(http.StatusConflict, gin.H{"message": fmt.Sprintf("Upload-Offset must be %d, the bytes received so far", upload.Offset)})
+		return
+	}
+
+	end := offset + size
+	if size == 0 || end > upload.Size {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Chunk must hold between 1 and %d bytes", upload.Size-offset)})
+		return
+	}
+
+	if end < upload.Size && size < storage.MinPartSize {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Every chunk but the last must hold at least %d bytes", storage.MinPartSize)})
+		return
+	}
+
+	// The server read timeout is meant for small bodies, a chunk gets as long
+	// as the lock on the upload lasts.
+	deadline := time.Now().Add(cfg.ChunkTimeout)
+	controller := http.NewResponseController(c.Writer)
+	if err := controller.SetReadDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	if err := controller.SetWriteDeadline(deadline.Add(time.Minute)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	err = h.app.Repositories.ResumableUpload.Lock(upload, deadline)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrEditConflict):
+			c.JSON(http.StatusConflict, gin.H{"message": "Another chunk of this upload is being received"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	digest, ok := h.receiveChunk(c, upload, size)
+	if !ok {
+		if err := h.app.Repositories.ResumableUpload.Unlock(upload.ID); err != nil {
+			h.app.Logger.Warn("failed to unlock resumable upload", "id", upload.ID, "error", err)
+		}
+		return
+	}
+
+	if upload.Offset < upload.Size {
+		err = h.app.Repositories.ResumableUpload.Advance(upload, offset)
+		if err != nil {
+			switch {
+			case errors.Is(err, repositories.ErrEditConflict):
+				c.JSON(http.StatusConflict, gin.H{"message": "The upload changed while the chunk was received"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			}
+			return
+		}
+
+		c.Header(uploadOffsetHeader, strconv.FormatInt(upload.Offset, 10))
+		c.JSON(http.StatusOK, types.ResponseSingleData[*models.ResumableUpload]{
+			Message: "Chunk received successfully",
+			Data:    upload,
+		})
+		return
+	}
+
+	h.complete(c, upload, digest)
+}
+
+// Delete aborts an upload and discards the chunks received.
+func (h *resumableUploadHandler) Delete(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	uploadID, err := lib.ContextParamUUID(c, "uploadID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid upload id must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	upload, err := h.app.Repositories.ResumableUpload.Delete(uid, uploadID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	h.abort(c, upload)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Resumable upload deleted successfully",
+	})
+}
+
+func (h *resumableUploadHandler) getUpload(c *gin.Context) (*models.ResumableUpload, bool) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return nil, false
+	}
+
+	uploadID, err := lib.ContextParamUUID(c, "uploadID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid upload id must be uuid format",
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+
+	upload, err := h.app.Repositories.ResumableUpload.Get(uid, uploadID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return nil, false
+	}
+
+	return upload, true
+}
+
+// receiveChunk streams size bytes of the request body into the next part of
+// upload, and moves upload past it. The type of the file is detected from the
+// first chunk, which also starts the multipart upload in storage. It returns
+// the SHA-256 of the bytes received so far, or responds and returns false.
+func (h *resumableUploadHandler) receiveChunk(c *gin.Context, upload *models.ResumableUpload, size int64) ([]byte, bool) {
+	ctx := c.Request.Context()
+
+	digest := sha256.New()
+	if err := digest.(encoding.BinaryUnmarshaler).UnmarshalBinary(upload.HashState); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return nil, false
+	}
+
+	var body io.Reader = http.MaxBytesReader(c.Writer, c.Request.Body, size)
+
+	if upload.MultipartID == "" {
+		reader := bufio.NewReaderSize(body, 512)
+		head, err := reader.Peek(512)
+		if err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return nil, false
+		}
+		body = reader
+
+		mimeType, err := sniffMimeType(head)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return nil, false
+		}
+
+		if !slices.Contains(h.app.Config.Storage.AllowedTypes, mimeType) {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"message": fmt.Sprintf("File type %s is not allowed", mimeType)})
+			return nil, false
+		}
+
+		multipartID, err := h.app.Storage.CreateMultipart(ctx, upload.KeyFile, mimeType)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return nil, false
+		}
+
+		upload.MimeType = mimeType
+		upload.MultipartID = multipartID
+	}
+
+	number := len(upload.PartETags) + 1
+	etag, err := h.app.Storage.PutPart(ctx, upload.KeyFile, upload.MultipartID, number, io.TeeReader(body, digest), size)
+	if err != nil {
+		if number == 1 {
+			h.abort(c, upload)
+		}
+
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Chunk is longer than its Content-Length"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return nil, false
+	}
+
+	hashState, err := digest.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return nil, false
+	}
+
+	upload.PartETags = append(upload.PartETags, etag)
+	upload.HashState = hashState
+	upload.Offset += size
+	upload.ExpiresAt = time.Now().Add(h.app.Config.Storage.ResumableExpiresIn)
+
+	return digest.Sum(nil), true
+}
+
+// complete joins the parts of an upload whose last chunk was received into an
+// Upload. A file that does not match its checksum is discarded.
+func (h *resumableUploadHandler) complete(c *gin.Context, upload *models.ResumableUpload, digest []byte) {
+	if hex.EncodeToString(digest) != upload.Checksum {
+		if _, err := h.app.Repositories.ResumableUpload.Delete(upload.UserID, upload.ID); err != nil {
+			h.app.Logger.Warn("failed to delete resumable upload", "id", upload.ID, "error", err)
+		}
+		h.abort(c, upload)
+
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "File does not match its checksum, the upload was discarded"})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	err := h.app.Storage.CompleteMultipart(ctx, upload.KeyFile, upload.MultipartID, upload.Parts())
+	if err != nil {
+		if err := h.app.Repositories.ResumableUpload.Unlock(upload.ID); err != nil {
+			h.app.Logger.Warn("failed to unlock resumable upload", "id", upload.ID, "error", err)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	file := &models.Upload{
+		Base: models.Base{
+			ID: upload.ID,
+		},
+		UserID:   &upload.UserID,
+		KeyFile:  upload.KeyFile,
+		FileName: upload.FileName,
+		MimeType: upload.MimeType,
+		Size:     upload.Size,
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.ResumableUpload.DB, func(tx *sql.Tx) error {
+		if _, err := h.app.Repositories.ResumableUpload.DeleteExec(tx, upload.UserID, upload.ID); err != nil {
+			return err
+		}
+
+		return h.app.Repositories.Upload.InsertExec(tx, file)
+	})
+	if err != nil {
+		// The multipart upload is gone, so the chunks cannot be sent again.
+		if _, err := h.app.Repositories.ResumableUpload.Delete(upload.UserID, upload.ID); err != nil && !errors.Is(err, repositories.ErrRecordNotFound) {
+			h.app.Logger.Warn("failed to delete resumable upload", "id", upload.ID, "error", err)
+		}
+		if err := h.app.Storage.Delete(ctx, upload.KeyFile); err != nil {
+			h.app.Logger.Warn("failed to delete uploaded file", "key", upload.KeyFile, "error", err)
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.Header(uploadOffsetHeader, strconv.FormatInt(upload.Offset, 10))
+	c.JSON(http.StatusOK, types.ResponseSingleData[*models.Upload]{
+		Message: "File uploaded successfully",
+		Data:    file,
+	})
+}
+
+// abort discards the parts stored for an upload whose row is gone, logging
+// failures as they only waste space.
+func (h *resumableUploadHandler) abort(c *gin.Context, upload *models.ResumableUpload) {
+	if upload.MultipartID == "" {
+		return
+	}
+
+	err := h.app.Storage.AbortMultipart(c.Request.Context(), upload.KeyFile, upload.MultipartID)
+	if err != nil {
+		h.app.Logger.Warn("failed to abort multipart upload", "key", upload.KeyFile, "error", err)
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"gintama/internal/app"
+	"gintama/internal/lib"
+	"gintama/internal/lib/revocation"
+	"gintama/internal/models"
+	"gintama/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+type revocationHandler struct {
+	app *app.Application
+}
+
+// RevokeUser revokes every token issued to the user so far and deletes their
+// sessions and API keys so they cannot be refreshed or reused.
+func (h *revocationHandler) RevokeUser(c *gin.Context) {
+	userID, err := lib.ContextParamUUID(c, "userID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid user id must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	err = h.app.Revocations.Revoke(h.newRevocation(revocation.UserKey(userID.String()), "user revoked"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	err = h.app.Repositories.Session.DeleteByUserID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	err = h.app.Repositories.APIKey.DeleteByUserID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	syncRevocations(h.app)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User tokens revoked successfully",
+	})
+}
+
+// RevokeSession revokes the sign-in the session belongs to.
+func (h *revocationHandler) RevokeSession(c *gin.Context) {
+	sessionID, err := lib.ContextParamUUID(c, "sessionID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid session id must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// The sessions trigger records the revocation of every deleted session.
+	err = h.app.Repositories.Session.DeleteFamilyBySessionID(sessionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	syncRevocations(h.app)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Session revoked successfully",
+	})
+}
+
+// RevokeToken revokes a single token by its jti.
+func (h *revocationHandler) RevokeToken(c *gin.Context) {
+	jti, err := lib.ContextParamUUID(c, "jti")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid jti must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	err = h.app.Revocations.Revoke(h.newRevocation(revocation.JTIKey(jti.String()), "token revoked"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Token revoked successfully",
+	})
+}
+
+// newRevocation revokes the key from now until every access token that could
+// carry it has expired.
+func (h *revocationHandler) newRevocation(key string, reason string) *models.TokenRevocation {
+	now := time.Now()
+
+	return &models.TokenRevocation{
+		Key:           key,
+		RevokedBefore: now,
+		ExpiresAt:     now.Add(h.app.Config.Auth.AccessTokenExpiresIn + h.app.Config.App.JWTLeeway),
+		Reason:        &reason,
+	}
+}
+
+// syncRevocations applies the revocations the sessions trigger just wrote on
+// this instance right away, other instances catch up on their next sync.
+func syncRevocations(app *app.Application) {
+	if err := app.Revocations.Sync(); err != nil {
+		app.Logger.Warn("failed to sync token revocations", "error", err)
+	}
+}
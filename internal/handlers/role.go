@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"gintama/internal/app"
@@ -217,3 +218,98 @@ func (h *roleHandler) Restore(c *gin.Context) {
 		Message: "data has been restored successfully",
 	})
 }
+
+func (h *roleHandler) Permissions(c *gin.Context) {
+	roleID, err := lib.ContextParamUUID(c, "roleID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid role id must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	permissions, err := h.app.Repositories.RolePermission.ListByRoleID(roleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseMultiData[*models.Permission]{
+		Message: "list data has been retrieved successfully",
+		Data:    permissions,
+		Meta: gin.H{
+			"total": len(permissions),
+		},
+	})
+}
+
+func (h *roleHandler) AttachPermission(c *gin.Context) {
+	roleID, err := lib.ContextParamUUID(c, "roleID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid role id must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	permissionID, err := lib.ContextParamUUID(c, "permissionID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid permission id must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	err = h.app.Repositories.RolePermission.Attach(roleID, permissionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[*models.Role]{
+		Message: "permission has been attached successfully",
+	})
+}
+
+func (h *roleHandler) DetachPermission(c *gin.Context) {
+	roleID, err := lib.ContextParamUUID(c, "roleID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid role id must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	permissionID, err := lib.ContextParamUUID(c, "permissionID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid permission id must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	err = h.app.Repositories.RolePermission.Detach(roleID, permissionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[*models.Role]{
+		Message: "permission has been detached successfully",
+	})
+}
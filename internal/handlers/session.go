@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"gintama/internal/app"
 	"gintama/internal/dto"
 	"gintama/internal/lib"
@@ -8,6 +9,7 @@ import (
 	"gintama/internal/repositories"
 	"gintama/internal/types"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -40,6 +42,8 @@ func (h *sessionHandler) Index(c *gin.Context) {
 		return
 	}
 
+	h.setIdleExpiry(sessions)
+
 	c.JSON(http.StatusOK, types.ResponseMultiData[*models.Session]{
 		Message: "list data has been retrieved successfully",
 		Data:    sessions,
@@ -48,3 +52,83 @@ func (h *sessionHandler) Index(c *gin.Context) {
 		},
 	})
 }
+
+func (h *sessionHandler) IndexOwn(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	sessionID, err := lib.ContextGetSessionID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	sessions, err := h.app.Repositories.Session.ListByUserID(uid, h.app.Config.Auth.SessionIdleSince(time.Now()))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	h.setIdleExpiry(sessions)
+
+	c.JSON(http.StatusOK, types.ResponseMultiData[*models.Session]{
+		Message: "list data has been retrieved successfully",
+		Data:    sessions,
+		Meta: gin.H{
+			"total":              len(sessions),
+			"current_session_id": sessionID,
+		},
+	})
+}
+
+// DeleteOwn revokes one of the caller's sessions, including every session
+// rotated from the same sign-in.
+func (h *sessionHandler) DeleteOwn(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	sessionID, err := lib.ContextParamUUID(c, "sessionID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid session id must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	err = h.app.Repositories.Session.DeleteBySessionID(uid, sessionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	syncRevocations(h.app)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Session revoked successfully",
+	})
+}
+
+// setIdleExpiry tells clients when each session expires unless it is used,
+// which is only known when sessions expire after inactivity.
+func (h *sessionHandler) setIdleExpiry(sessions []*models.Session) {
+	idleTimeout := h.app.Config.Auth.SessionIdleTimeout
+	if idleTimeout <= 0 {
+		return
+	}
+
+	for _, session := range sessions {
+		session.IdleExpiresAt = lib.TimePtr(session.LastSeenAt.Add(idleTimeout))
+	}
+}
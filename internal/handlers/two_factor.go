@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"gintama/internal/app"
+	"gintama/internal/dto"
+	"gintama/internal/lib"
+	"gintama/internal/lib/totp"
+	"gintama/internal/models"
+	"gintama/internal/repositories"
+	"gintama/internal/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// recoveryCodeCount is the number of recovery codes issued when two-factor
+// authentication is confirmed.
+const recoveryCodeCount = 10
+
+type twoFactorHandler struct {
+	app *app.Application
+}
+
+func (h *twoFactorHandler) Setup(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	user, err := h.app.Repositories.User.Get(uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	authenticator := totp.New()
+	secret, err := authenticator.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	err = h.app.Repositories.UserTwoFactor.Upsert(&models.UserTwoFactor{
+		ID:     user.ID,
+		Secret: secret,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrInsertDuplicate):
+			c.JSON(http.StatusConflict, gin.H{"message": "Two-factor authentication is already enabled"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[any]{
+		Message: "Scan the QR code with your authenticator app, then confirm with a code",
+		Data: gin.H{
+			"secret":      secret,
+			"otpauth_uri": authenticator.URI(h.app.Config.App.Name, user.Email, secret),
+		},
+	})
+}
+
+func (h *twoFactorHandler) Confirm(c *gin.Context) {
+	var dto dto.TwoFactorConfirm
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			c.JSON(http.StatusBadRequest, lib.WrapValidationError(e.MessageRecord))
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	twoFactor, err := h.app.Repositories.UserTwoFactor.Get(uid)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Two-factor setup has not been started"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	if twoFactor.ConfirmedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"message": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	step, err := totp.New().Validate(twoFactor.Secret, dto.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, totp.ErrInvalidCode):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid two-factor code"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	codes, err := totp.RecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	recoveryCodes := make([]*models.UserRecoveryCode, 0, len(codes))
+	for _, code := range codes {
+		recoveryCodes = append(recoveryCodes, &models.UserRecoveryCode{
+			ID:     uuid.Must(uuid.NewV7()),
+			UserID: uid,
+			Code:   lib.HashToken(totp.NormalizeRecoveryCode(code)),
+		})
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.UserTwoFactor.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.UserTwoFactor.ConfirmExec(tx, uid, step)
+		if err != nil {
+			return err
+		}
+
+		err = h.app.Repositories.UserRecoveryCode.DeleteByUserIDExec(tx, uid)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.UserRecoveryCode.InsertExec(tx, recoveryCodes...)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrEditConflict):
+			c.JSON(http.StatusConflict, gin.H{"message": "Two-factor authentication is already enabled"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	// Recovery codes are only stored hashed, so this is the one time they are shown.
+	c.JSON(http.StatusOK, types.ResponseSingleData[any]{
+		Message: "Two-factor authentication enabled, store the recovery codes somewhere safe",
+		Data: gin.H{
+			"recovery_codes": codes,
+		},
+	})
+}
+
+func (h *twoFactorHandler) Disable(c *gin.Context) {
+	var dto dto.TwoFactorDisable
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			c.JSON(http.StatusBadRequest, lib.WrapValidationError(e.MessageRecord))
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	twoFactor, err := h.app.Repositories.UserTwoFactor.Get(uid)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Two-factor authentication is not enabled"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	if twoFactor.ConfirmedAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Two-factor authentication is not enabled"})
+		return
+	}
+
+	step, err := totp.New().Validate(twoFactor.Secret, dto.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, totp.ErrInvalidCode):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid two-factor code"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	err = h.app.Repositories.UserTwoFactor.UseStep(twoFactor.ID, step)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrEditConflict):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid two-factor code"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.UserTwoFactor.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.UserRecoveryCode.DeleteByUserIDExec(tx, uid)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.UserTwoFactor.DeleteExec(tx, uid)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication disabled",
+	})
+}
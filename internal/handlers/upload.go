@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"unicode/utf8"
+
+	"gintama/internal/app"
+	"gintama/internal/lib"
+	"gintama/internal/lib/imaging"
+	"gintama/internal/models"
+	"gintama/internal/repositories"
+	"gintama/internal/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// avatarSize is the width in pixels of a stored avatar, which is cropped
+	// square.
+	avatarSize = 512
+	// uploadFormOverhead is allowed on top of the maximum file size for the
+	// boundaries and headers of the multipart body.
+	uploadFormOverhead = 1 << 20
+	uploadFileField    = "file"
+	maxFileNameLength  = 255
+)
+
+// avatarThumbnailSizes are the widths in pixels of the thumbnails stored next
+// to an avatar.
+var avatarThumbnailSizes = []int64{256, 64}
+
+type uploadHandler struct {
+	app *app.Application
+}
+
+// Create stores the file sent in the file field of a multipart body. Its type
+// is detected from the content, the type sent by the client is ignored.
+func (h *uploadHandler) Create(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	fileHeader, file, mimeType, ok := h.receiveFile(c, h.app.Config.Storage.AllowedTypes)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	upload, err := h.storeFile(c, uid, fileHeader.Filename, file, fileHeader.Size, mimeType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[*models.Upload]{
+		Message: "File uploaded successfully",
+		Data:    upload,
+	})
+}
+
+func (h *uploadHandler) Show(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	uploadID, err := lib.ContextParamUUID(c, "uploadID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid upload id must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	upload, err := h.app.Repositories.Upload.Get(uid, uploadID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[*models.Upload]{
+		Message: "get data has been retrieved successfully",
+		Data:    upload,
+	})
+}
+
+// Delete removes the upload and its stored file.
+func (h *uploadHandler) Delete(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	uploadID, err := lib.ContextParamUUID(c, "uploadID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid upload id must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	upload, err := h.app.Repositories.Upload.Delete(uid, uploadID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	// The row is gone, so a file left behind is only wasted space.
+	h.deleteObjects(c, upload.Keys()...)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Upload deleted successfully",
+	})
+}
+
+// PutAvatar replaces the avatar of the user with the image sent in the file
+// field of a multipart body. The image is decoded and encoded again, which
+// drops its EXIF metadata, cropped square to avatarSize and stored with
+// thumbnails of avatarThumbnailSizes. The previous avatar is deleted.
+func (h *uploadHandler) PutAvatar(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	fileHeader, file, _, ok := h.receiveFile(c, imaging.SupportedTypes)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	img, format, err := imaging.Decode(file)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "File is not a valid image", "error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	uploadID := uuid.Must(uuid.NewV7())
+
+	upload := &models.Upload{
+		Base: models.Base{
+			ID: uploadID,
+		},
+		UserID:   &uid,
+		KeyFile:  fmt.Sprintf("uploads/%s/%s", uid, uploadID),
+		FileName: cleanFileName(fileHeader.Filename),
+	}
+
+	var stored []string
+	putImage := func(key string, size int) error {
+		var buf bytes.Buffer
+		mimeType, err := imaging.Encode(&buf, imaging.Thumbnail(img, size), format)
+		if err != nil {
+			return err
+		}
+
+		if key == upload.KeyFile {
+			upload.MimeType = mimeType
+			upload.Size = int64(buf.Len())
+		}
+
+		err = h.app.Storage.Put(ctx, key, &buf, int64(buf.Len()), mimeType)
+		if err != nil {
+			return err
+		}
+		stored = append(stored, key)
+
+		return nil
+	}
+
+	err = putImage(upload.KeyFile, avatarSize)
+	for _, size := range avatarThumbnailSizes {
+		if err != nil {
+			break
+		}
+		upload.ThumbnailSizes = append(upload.ThumbnailSizes, size)
+		err = putImage(upload.ThumbnailKey(size), int(size))
+	}
+	if err != nil {
+		h.deleteObjects(c, stored...)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	err = h.app.Repositories.Upload.Insert(upload)
+	if err != nil {
+		h.deleteObjects(c, stored...)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	previousID, err := h.app.Repositories.User.SetUpload(uid, upload.ID)
+	if err != nil {
+		if _, err := h.app.Repositories.Upload.Delete(uid, upload.ID); err != nil {
+			h.app.Logger.Warn("failed to delete upload", "id", upload.ID, "error", err)
+		}
+		h.deleteObjects(c, stored...)
+
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	// The previous avatar is no longer linked, failing to remove it only
+	// leaves an upload the user can still delete.
+	if previousID != nil && *previousID != upload.ID {
+		previous, err := h.app.Repositories.Upload.Delete(uid, *previousID)
+		switch {
+		case err == nil:
+			h.deleteObjects(c, previous.Keys()...)
+		case !errors.Is(err, repositories.ErrRecordNotFound):
+			h.app.Logger.Warn("failed to delete previous avatar", "id", *previousID, "error", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[*models.Upload]{
+		Message: "Avatar updated successfully",
+		Data:    upload,
+	})
+}
+
+// receiveFile opens the file of a multipart body and detects its type from
+// the first bytes. It responds and returns false when the file is missing,
+// larger than upload-max-size or not of one of allowedTypes.
+func (h *uploadHandler) receiveFile(c *gin.Context, allowedTypes []string) (*multipart.FileHeader, multipart.File, string, bool) {
+	maxSize := h.app.Config.Storage.MaxUploadSize
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+uploadFormOverhead)
+
+	fileHeader, err := c.FormFile(uploadFileField)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": fmt.Sprintf("File must not be larger than %d bytes", maxSize)})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"message": "A file is required in the file field of a multipart form", "error": err.Error()})
+		}
+		return nil, nil, "", false
+	}
+
+	if fileHeader.Size > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": fmt.Sprintf("File must not be larger than %d bytes", maxSize)})
+		return nil, nil, "", false
+	}
+
+	if fileHeader.Size == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "File is empty"})
+		return nil, nil, "", false
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return nil, nil, "", false
+	}
+
+	mimeType, err := detectMimeType(file)
+	if err != nil {
+		file.Close()
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return nil, nil, "", false
+	}
+
+	if !slices.Contains(allowedTypes, mimeType) {
+		file.Close()
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"message": fmt.Sprintf("File type %s is not allowed", mimeType)})
+		return nil, nil, "", false
+	}
+
+	return fileHeader, file, mimeType, true
+}
+
+// storeFile puts the file in storage and records it as an upload of the user.
+func (h *uploadHandler) storeFile(c *gin.Context, uid uuid.UUID, fileName string, body io.Reader, size int64, mimeType string) (*models.Upload, error) {
+	uploadID := uuid.Must(uuid.NewV7())
+
+	upload := &models.Upload{
+		Base: models.Base{
+			ID: uploadID,
+		},
+		UserID:   &uid,
+		KeyFile:  fmt.Sprintf("uploads/%s/%s", uid, uploadID),
+		FileName: cleanFileName(fileName),
+		MimeType: mimeType,
+		Size:     size,
+	}
+
+	err := h.app.Storage.Put(c.Request.Context(), upload.KeyFile, body, size, mimeType)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.app.Repositories.Upload.Insert(upload)
+	if err != nil {
+		h.deleteObjects(c, upload.KeyFile)
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+// deleteObjects removes stored files whose upload is gone, logging failures
+// as they only waste space.
+func (h *uploadHandler) deleteObjects(c *gin.Context, keys ...string) {
+	for _, key := range keys {
+		err := h.app.Storage.Delete(c.Request.Context(), key)
+		if err != nil {
+			h.app.Logger.Warn("failed to delete uploaded file", "key", key, "error", err)
+		}
+	}
+}
+
+// detectMimeType sniffs the type of file from its first 512 bytes and rewinds
+// it.
+func detectMimeType(file multipart.File) (string, error) {
+	head := make([]byte, 512)
+
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	return sniffMimeType(head[:n])
+}
+
+// sniffMimeType returns the type of content starting with head, without
+// parameters.
+func sniffMimeType(head []byte) (string, error) {
+	mimeType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "", err
+	}
+
+	return mimeType, nil
+}
+
+// cleanFileName keeps the base name the client sent, for display only. Keys
+// never contain it.
+func cleanFileName(fileName string) string {
+	fileName = filepath.Base(filepath.Clean("/" + fileName))
+	if fileName == "/" || fileName == "." || !utf8.ValidString(fileName) {
+		return "file"
+	}
+
+	if len(fileName) > maxFileNameLength {
+		fileName = fileName[:maxFileNameLength]
+		for !utf8.ValidString(fileName) {
+			fileName = fileName[:len(fileName)-1]
+		}
+	}
+
+	return fileName
+}
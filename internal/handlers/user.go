@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
+	"strings"
 
 	"gintama/internal/app"
 	"gintama/internal/dto"
 	"gintama/internal/lib"
+	"gintama/internal/lib/lockout"
 	"gintama/internal/models"
 	"gintama/internal/repositories"
 	"gintama/internal/types"
@@ -73,6 +77,92 @@ func (h *userHandler) Show(c *gin.Context) {
 	})
 }
 
+// ShowOwn returns the profile of the caller.
+func (h *userHandler) ShowOwn(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	user, err := h.app.Repositories.User.Get(uid)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[*models.User]{
+		Message: "get data has been retrieved successfully",
+		Data:    user,
+	})
+}
+
+// UpdateOwn edits the name and phone of the caller. Email, role and account
+// status stay with the admin routes.
+func (h *userHandler) UpdateOwn(c *gin.Context) {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	var dto dto.UserUpdateOwn
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			c.JSON(http.StatusBadRequest, lib.WrapValidationError(e.MessageRecord))
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	user, err := h.app.Repositories.User.Get(uid)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	if firstName := strings.TrimSpace(dto.FirstName); firstName != "" {
+		user.FirstName = firstName
+	}
+
+	if dto.LastName != nil {
+		user.LastName = emptyToNil(strings.TrimSpace(*dto.LastName))
+	}
+
+	if dto.Phone != nil {
+		user.Phone = emptyToNil(strings.TrimSpace(*dto.Phone))
+	}
+
+	err = h.app.Repositories.User.UpdateProfile(uid, user)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[*models.User]{
+		Message: "data has been updated successfully",
+		Data:    user,
+	})
+}
+
 func (h *userHandler) Create(c *gin.Context) {
 	var dto dto.UserCreate
 
@@ -203,12 +293,22 @@ func (h *userHandler) SoftDelete(c *gin.Context) {
 		return
 	}
 
-	err = h.app.Repositories.User.SoftDelete(userID)
+	// Deleted users are signed out everywhere, like blocked users.
+	err = lib.WithTransaction(h.app.Repositories.User.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.User.SoftDeleteExec(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.Session.DeleteByUserIDExec(tx, userID)
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
+	syncRevocations(h.app)
+
 	c.JSON(http.StatusOK, types.ResponseSingleData[*models.User]{
 		Message: "data has been soft deleted successfully",
 	})
@@ -234,3 +334,119 @@ func (h *userHandler) Restore(c *gin.Context) {
 		Message: "data has been restored successfully",
 	})
 }
+
+func (h *userHandler) Block(c *gin.Context) {
+	userID, err := lib.ContextParamUUID(c, "userID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid user id must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	if uid == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "you can't block your own account"})
+		return
+	}
+
+	// Sessions are revoked in the same transaction so the user is signed out
+	// everywhere the moment the block is stored.
+	err = lib.WithTransaction(h.app.Repositories.User.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.User.BlockExec(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.Session.DeleteByUserIDExec(tx, userID)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	syncRevocations(h.app)
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[*models.User]{
+		Message: "data has been blocked successfully",
+	})
+}
+
+func (h *userHandler) Unblock(c *gin.Context) {
+	userID, err := lib.ContextParamUUID(c, "userID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid user id must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	err = h.app.Repositories.User.Unblock(userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[*models.User]{
+		Message: "data has been unblocked successfully",
+	})
+}
+
+// Unlock clears the failed sign in attempts of a user, lifting a lockout
+// before it expires.
+func (h *userHandler) Unlock(c *gin.Context) {
+	userID, err := lib.ContextParamUUID(c, "userID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid user id must be uuid format",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	user, err := h.app.Repositories.User.Get(userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	err = h.app.Repositories.LoginAttempt.Delete(lockout.EmailKey(user.Email))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, types.ResponseSingleData[*models.User]{
+		Message: "data has been unlocked successfully",
+	})
+}
+
+func emptyToNil(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return &value
+}
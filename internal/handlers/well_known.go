@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gintama/internal/app"
+
+	"github.com/gin-gonic/gin"
+)
+
+type wellKnownHandler struct {
+	app *app.Application
+}
+
+// JWKS publishes the public keys tokens are signed with so other services can
+// verify them without sharing a secret. Retired keys stay listed until the
+// tokens they signed have expired.
+func (h *wellKnownHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.app.JWT.JWKS())
+}
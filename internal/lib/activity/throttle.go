@@ -0,0 +1,69 @@
+package activity
+
+import (
+	"sync"
+	"time"
+)
+
+// Throttle decides when the activity of a key is worth recording, so a busy
+// session is written at most once per interval by each instance.
+type Throttle struct {
+	mu        sync.Mutex
+	interval  time.Duration
+	seen      map[string]time.Time
+	lastSweep time.Time
+}
+
+// New returns a throttle letting each key through once per interval.
+func New(interval time.Duration) *Throttle {
+	return &Throttle{
+		interval: interval,
+		seen:     make(map[string]time.Time),
+	}
+}
+
+// Allow reports whether the key was not let through during the last interval,
+// and if so records now as its latest activity.
+func (t *Throttle) Allow(key string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if now.Sub(t.lastSweep) >= t.interval {
+		t.sweep(now)
+	}
+
+	if last, ok := t.seen[key]; ok && now.Sub(last) < t.interval {
+		return false
+	}
+
+	t.seen[key] = now
+
+	return true
+}
+
+// Forget lets the next activity of the key through, e.g. after recording it
+// failed.
+func (t *Throttle) Forget(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.seen, key)
+}
+
+// Len returns the number of keys held.
+func (t *Throttle) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.seen)
+}
+
+func (t *Throttle) sweep(now time.Time) {
+	for key, last := range t.seen {
+		if now.Sub(last) >= t.interval {
+			delete(t.seen, key)
+		}
+	}
+
+	t.lastSweep = now
+}
@@ -0,0 +1,52 @@
+package activity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottleAllow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	throttle := New(time.Minute)
+
+	if !throttle.Allow("a", now) {
+		t.Errorf("Allow() first activity = false, want true")
+	}
+
+	if throttle.Allow("a", now.Add(30*time.Second)) {
+		t.Errorf("Allow() within the interval = true, want false")
+	}
+
+	if !throttle.Allow("b", now.Add(30*time.Second)) {
+		t.Errorf("Allow() of another key = false, want true")
+	}
+
+	if !throttle.Allow("a", now.Add(time.Minute)) {
+		t.Errorf("Allow() after the interval = false, want true")
+	}
+}
+
+func TestThrottleForget(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	throttle := New(time.Minute)
+
+	throttle.Allow("a", now)
+	throttle.Forget("a")
+
+	if !throttle.Allow("a", now.Add(time.Second)) {
+		t.Errorf("Allow() after Forget() = false, want true")
+	}
+}
+
+func TestThrottleSweep(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	throttle := New(time.Minute)
+
+	throttle.Allow("a", now)
+	throttle.Allow("b", now.Add(30*time.Second))
+	throttle.Allow("c", now.Add(time.Minute))
+
+	if got := throttle.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+}
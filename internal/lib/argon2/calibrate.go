@@ -0,0 +1,72 @@
+package argon2
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Calibration is the outcome of Calibrate.
+type Calibration struct {
+	Params Params
+	// Duration is the time one hash took with Params on this machine.
+	Duration time.Duration
+}
+
+// Calibrate picks the most iterations that hash within target using memory KiB
+// and parallel lanes, measuring on the current machine. When a single
+// iteration is already slower than target, memory is halved until it fits.
+func Calibrate(target time.Duration, memory uint32, parallel uint8) (Calibration, error) {
+	if target <= 0 {
+		return Calibration{}, errors.New("target must be greater than 0")
+	}
+
+	if parallel == 0 || memory < 8*uint32(parallel) {
+		return Calibration{}, errors.New("memory must be at least 8 KiB per lane")
+	}
+
+	params := Params{Memory: memory, Iterations: 1, Parallel: parallel}
+
+	took := measure(params)
+	for took > target && params.Memory/2 >= 8*uint32(parallel) {
+		params.Memory /= 2
+		took = measure(params)
+	}
+
+	if took >= target {
+		return Calibration{Params: params, Duration: took}, nil
+	}
+
+	// Hashing time grows linearly with iterations, so estimate from one
+	// iteration and step back while the estimate overshoots.
+	params.Iterations = max(1, uint32(target/took))
+	took = measure(params)
+	for took > target && params.Iterations > 1 {
+		params.Iterations--
+		took = measure(params)
+	}
+
+	return Calibration{Params: params, Duration: took}, nil
+}
+
+// measure returns the fastest of a few hashes with params, which filters out
+// scheduling noise.
+func measure(params Params) time.Duration {
+	const runs = 3
+
+	password := []byte("calibration password")
+	salt := make([]byte, saltLength)
+
+	var fastest time.Duration
+	for i := range runs {
+		start := time.Now()
+		argon2.IDKey(password, salt, params.Iterations, params.Memory, params.Parallel, keyLength)
+
+		if took := time.Since(start); i == 0 || took < fastest {
+			fastest = took
+		}
+	}
+
+	return fastest
+}
@@ -0,0 +1,44 @@
+package argon2
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalibrate(t *testing.T) {
+	target := 20 * time.Millisecond
+
+	got, err := Calibrate(target, 8*1024, 1)
+	if err != nil {
+		t.Fatalf("Calibrate() error = %v", err)
+	}
+
+	if got.Params.Iterations == 0 || got.Params.Parallel != 1 || got.Params.Memory == 0 {
+		t.Errorf("Calibrate() params = %+v, want usable parameters", got.Params)
+	}
+
+	if got.Params.Iterations > 1 && got.Duration > target {
+		t.Errorf("Calibrate() duration = %v, want at most %v", got.Duration, target)
+	}
+}
+
+func TestCalibrateRejectsParams(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   time.Duration
+		memory   uint32
+		parallel uint8
+	}{
+		{"Zero target", 0, 8 * 1024, 1},
+		{"Zero lanes", time.Second, 8 * 1024, 0},
+		{"Too little memory", time.Second, 8, 2},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Calibrate(tc.target, tc.memory, tc.parallel); err == nil {
+				t.Errorf("Calibrate() error = nil, want an error")
+			}
+		})
+	}
+}
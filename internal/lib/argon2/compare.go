@@ -9,7 +9,9 @@ import (
 	"golang.org/x/crypto/argon2"
 )
 
-func (h *Argon2) Compare(encodedHash string, password string) (match bool, err error) {
+// Compare reports whether password matches encodedHash, using the parameters
+// stored in the hash.
+func (h *Argon2) Compare(password string, encodedHash string) (match bool, err error) {
 	cfg, salt, hash, err := h.decodeHash(encodedHash)
 	if err != nil {
 		return false, err
@@ -19,6 +21,18 @@ func (h *Argon2) Compare(encodedHash string, password string) (match bool, err e
 	return subtle.ConstantTimeCompare(generatedHash, hash) == 1, nil
 }
 
+// NeedsRehash reports whether encodedHash was made with parameters other than
+// those of the hasher, so it should be replaced the next time the password is
+// known.
+func (h *Argon2) NeedsRehash(encodedHash string) bool {
+	cfg, _, _, err := h.decodeHash(encodedHash)
+	if err != nil {
+		return true
+	}
+
+	return cfg != h.config()
+}
+
 func (h *Argon2) decodeHash(encodedHash string) (cfg argonConfig, salt []byte, hash []byte, err error) {
 	vals := strings.Split(encodedHash, "$")
 
@@ -121,3 +121,36 @@ func TestCompare(t *testing.T) {
 		})
 	}
 }
+
+func TestNeedsRehash(t *testing.T) {
+	params := Params{Memory: 8 * 1024, Iterations: 2, Parallel: 1}
+	arg := NewWithParams(params)
+
+	current, err := arg.Generate("password")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	outdated, err := NewWithParams(Params{Memory: 8 * 1024, Iterations: 1, Parallel: 1}).Generate("password")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		encodedHash string
+		want        bool
+	}{
+		{"Current parameters", current, false},
+		{"Outdated parameters", outdated, true},
+		{"Invalid hash", "invalid_hash", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := arg.NeedsRehash(tc.encodedHash); got != tc.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -8,16 +8,9 @@ import (
 	"golang.org/x/crypto/argon2"
 )
 
+// Generate hashes password with the parameters of the hasher.
 func (h *Argon2) Generate(password string) (string, error) {
-	config := argonConfig{
-		SaltLength: 16,
-		KeyLength:  32,
-		Iterations: 10,
-		Memory:     64 * 1024,
-		Parallel:   2,
-	}
-
-	encodedHash, err := h.generateFromPlainText(password, config)
+	encodedHash, err := h.generateFromPlainText(password, h.config())
 	if err != nil {
 		return "", err
 	}
@@ -126,3 +126,30 @@ func TestGenerate(t *testing.T) {
 		t.Errorf("Generated hash incorrectly matches wrong password")
 	}
 }
+
+func TestNewWithParams(t *testing.T) {
+	params := Params{Memory: 8 * 1024, Iterations: 1, Parallel: 1}
+
+	encodedHash, err := NewWithParams(params).Generate("password")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if NewWithParams(params).NeedsRehash(encodedHash) {
+		t.Errorf("Generate() hash %s does not use the given parameters", encodedHash)
+	}
+
+	if !New().NeedsRehash(encodedHash) {
+		t.Errorf("Generate() hash %s uses the default parameters", encodedHash)
+	}
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	arg := New()
+
+	for b.Loop() {
+		if _, err := arg.Generate("benchmark password"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
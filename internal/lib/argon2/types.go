@@ -1,6 +1,16 @@
 package argon2
 
-type Argon2 struct{}
+// Argon2 hashes passwords with argon2id. The zero value uses DefaultParams.
+type Argon2 struct {
+	params Params
+}
+
+// Params are the cost parameters of new hashes. Memory is in KiB.
+type Params struct {
+	Memory     uint32
+	Iterations uint32
+	Parallel   uint8
+}
 
 type argonConfig struct {
 	SaltLength uint32
@@ -10,6 +20,41 @@ type argonConfig struct {
 	Parallel   uint8
 }
 
+const (
+	saltLength = 16
+	keyLength  = 32
+)
+
+// DefaultParams are the parameters of hashers returned by New.
+func DefaultParams() Params {
+	return Params{
+		Memory:     64 * 1024,
+		Iterations: 10,
+		Parallel:   2,
+	}
+}
+
+// New returns a hasher using DefaultParams.
 func New() *Argon2 {
-	return &Argon2{}
+	return &Argon2{params: DefaultParams()}
+}
+
+// NewWithParams returns a hasher using params.
+func NewWithParams(params Params) *Argon2 {
+	return &Argon2{params: params}
+}
+
+func (h *Argon2) config() argonConfig {
+	params := h.params
+	if params == (Params{}) {
+		params = DefaultParams()
+	}
+
+	return argonConfig{
+		SaltLength: saltLength,
+		KeyLength:  keyLength,
+		Iterations: params.Iterations,
+		Memory:     params.Memory,
+		Parallel:   params.Parallel,
+	}
 }
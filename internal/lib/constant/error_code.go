@@ -0,0 +1,10 @@
+package constant
+
+// Error codes returned next to the message so clients can branch on them.
+const (
+	ErrCodeAccountNotVerified = "ACCOUNT_NOT_VERIFIED"
+	ErrCodeAccountBlocked     = "ACCOUNT_BLOCKED"
+	ErrCodeAccountLocked      = "ACCOUNT_LOCKED"
+	ErrCodeTooManyAttempts    = "TOO_MANY_ATTEMPTS"
+	ErrCodeIdentityNotLinked  = "IDENTITY_NOT_LINKED"
+)
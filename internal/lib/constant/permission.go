@@ -0,0 +1,13 @@
+package constant
+
+const (
+	PermissionUsersRead        = "users:read"
+	PermissionUsersWrite       = "users:write"
+	PermissionRolesRead        = "roles:read"
+	PermissionRolesWrite       = "roles:write"
+	PermissionSessionsRead     = "sessions:read"
+	PermissionSessionsWrite    = "sessions:write"
+	PermissionPermissionsRead  = "permissions:read"
+	PermissionPermissionsWrite = "permissions:write"
+	PermissionUploadsWrite     = "uploads:write"
+)
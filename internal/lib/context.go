@@ -20,6 +20,33 @@ func ContextSetUID(c *gin.Context, uid uuid.UUID) {
 	c.Set("uid", uid.String())
 }
 
+func ContextGetSessionID(c *gin.Context) (uuid.UUID, error) {
+	if sessionID, exists := c.Get("session_id"); exists {
+		sessionID := uuid.MustParse(sessionID.(string))
+		return sessionID, nil
+	}
+
+	return uuid.Nil, errors.New("can't find get context session, please check your authorization")
+}
+
+func ContextSetSessionID(c *gin.Context, sessionID uuid.UUID) {
+	c.Set("session_id", sessionID.String())
+}
+
+// ContextGetScopes returns the scopes of the API key the request was
+// authorized with. ok is false for requests authorized by a user session.
+func ContextGetScopes(c *gin.Context) ([]string, bool) {
+	if scopes, exists := c.Get("scopes"); exists {
+		return scopes.([]string), true
+	}
+
+	return nil, false
+}
+
+func ContextSetScopes(c *gin.Context, scopes []string) {
+	c.Set("scopes", scopes)
+}
+
 func ContextParamUUID(c *gin.Context, key string) (uuid.UUID, error) {
 	str := c.Param(key)
 	return uuid.Parse(str)
@@ -0,0 +1,54 @@
+package csrf
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"gintama/internal/lib"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The double-submit token is set in a cookie scripts of the client can read,
+// and has to be echoed in the header. Other sites can make the browser send
+// the cookie but cannot read it to set the header.
+const (
+	CookieName = "csrf_token"
+	HeaderName = "X-CSRF-Token"
+)
+
+var (
+	ErrMissingToken = errors.New("missing csrf token")
+	ErrInvalidToken = errors.New("invalid csrf token")
+)
+
+// Generate returns a new random token.
+func Generate() (string, error) {
+	return lib.RandomToken(32)
+}
+
+// IsSafeMethod reports whether the method only reads, and so needs no token.
+func IsSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// Verify checks that the header repeats the token of the cookie.
+func Verify(c *gin.Context) error {
+	cookie, _ := c.Cookie(CookieName)
+	header := c.GetHeader(HeaderName)
+	if cookie == "" || header == "" {
+		return ErrMissingToken
+	}
+
+	if subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) != 1 {
+		return ErrInvalidToken
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package csrf
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		wantErr error
+	}{
+		{
+			name: "Header matches cookie",
+			headers: map[string]string{
+				"Cookie":       "csrf_token=abc123",
+				"X-CSRF-Token": "abc123",
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Header differs from cookie",
+			headers: map[string]string{
+				"Cookie":       "csrf_token=abc123",
+				"X-CSRF-Token": "abc124",
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "Missing header",
+			headers: map[string]string{
+				"Cookie": "csrf_token=abc123",
+			},
+			wantErr: ErrMissingToken,
+		},
+		{
+			name: "Missing cookie",
+			headers: map[string]string{
+				"X-CSRF-Token": "abc123",
+			},
+			wantErr: ErrMissingToken,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			app := gin.New()
+
+			var gotErr error
+			app.POST("/test", func(c *gin.Context) {
+				gotErr = Verify(c)
+				c.String(http.StatusOK, "ok")
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/test", nil)
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+			app.ServeHTTP(httptest.NewRecorder(), req)
+
+			if !errors.Is(gotErr, tc.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", gotErr, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsSafeMethod(t *testing.T) {
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodOptions} {
+		if !IsSafeMethod(method) {
+			t.Errorf("IsSafeMethod(%s) = false, want true", method)
+		}
+	}
+
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodTrace} {
+		if IsSafeMethod(method) {
+			t.Errorf("IsSafeMethod(%s) = true, want false", method)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	first, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	second, _ := Generate()
+	if first == "" || first == second {
+		t.Errorf("Generate() = %q and %q, want distinct tokens", first, second)
+	}
+}
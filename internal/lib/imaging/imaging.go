@@ -0,0 +1,138 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// MaxPixels caps the size of images Decode accepts, as a small compressed file
+// can expand to gigabytes of pixels.
+const MaxPixels = 40_000_000
+
+var (
+	ErrUnsupportedFormat = errors.New("image format is not supported")
+	ErrTooLarge          = errors.New("image dimensions are too large")
+)
+
+// SupportedTypes are the MIME types Decode reads.
+var SupportedTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+// Decode reads a JPEG, PNG or GIF image, turned upright according to its EXIF
+// orientation. Only the first frame of an animated GIF is kept.
+func Decode(r io.Reader) (*image.RGBA, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			return nil, "", ErrUnsupportedFormat
+		}
+		return nil, "", err
+	}
+
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return nil, "", ErrTooLarge
+	}
+
+	var img image.Image
+	switch format {
+	case "jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case "png":
+		img, err = png.Decode(bytes.NewReader(data))
+	case "gif":
+		img, err = gif.Decode(bytes.NewReader(data))
+	default:
+		return nil, "", ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	rgba := toRGBA(img)
+	if format == "jpeg" {
+		rgba = orient(rgba, Orientation(data))
+	}
+
+	return rgba, format, nil
+}
+
+// Encode writes img as a JPEG when format is jpeg and as a PNG otherwise, and
+// returns the MIME type written. Metadata of the source, such as EXIF, is not
+// carried over.
+func Encode(w io.Writer, img image.Image, format string) (string, error) {
+	if format == "jpeg" {
+		return "image/jpeg", jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	}
+
+	return "image/png", png.Encode(w, img)
+}
+
+// Thumbnail crops the center square of src and scales it down to size pixels
+// wide. Images smaller than size are cropped but not enlarged.
+func Thumbnail(src *image.RGBA, size int) *image.RGBA {
+	bounds := src.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+
+	x0 := bounds.Min.X + (bounds.Dx()-side)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-side)/2
+	square := src.SubImage(image.Rect(x0, y0, x0+side, y0+side)).(*image.RGBA)
+
+	return resize(square, min(size, side), min(size, side))
+}
+
+// resize scales src to width by height, averaging the source pixels each
+// destination pixel covers. Colors are premultiplied, so transparent pixels do
+// not darken their neighbours.
+func resize(src *image.RGBA, width int, height int) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for dy := range height {
+		sy0 := bounds.Min.Y + dy*bounds.Dy()/height
+		sy1 := max(sy0+1, bounds.Min.Y+((dy+1)*bounds.Dy()+height-1)/height)
+
+		for dx := range width {
+			sx0 := bounds.Min.X + dx*bounds.Dx()/width
+			sx1 := max(sx0+1, bounds.Min.X+((dx+1)*bounds.Dx()+width-1)/width)
+
+			var r, g, b, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				offset := src.PixOffset(sx0, sy)
+				for sx := sx0; sx < sx1; sx++ {
+					r += uint64(src.Pix[offset])
+					g += uint64(src.Pix[offset+1])
+					b += uint64(src.Pix[offset+2])
+					a += uint64(src.Pix[offset+3])
+					offset += 4
+					n++
+				}
+			}
+
+			offset := dst.PixOffset(dx, dy)
+			dst.Pix[offset] = uint8((r + n/2) / n)
+			dst.Pix[offset+1] = uint8((g + n/2) / n)
+			dst.Pix[offset+2] = uint8((b + n/2) / n)
+			dst.Pix[offset+3] = uint8((a + n/2) / n)
+		}
+	}
+
+	return dst
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+
+	return rgba
+}
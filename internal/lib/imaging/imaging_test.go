@@ -0,0 +1,136 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func solid(width, height int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestThumbnail(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+
+	tests := []struct {
+		name       string
+		width      int
+		height     int
+		size       int
+		wantLength int
+	}{
+		{"Landscape is cropped and scaled down", 400, 300, 64, 64},
+		{"Portrait is cropped and scaled down", 300, 400, 128, 128},
+		{"Small image is not enlarged", 40, 50, 64, 40},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Thumbnail(solid(tc.width, tc.height, red), tc.size)
+
+			if got.Bounds().Dx() != tc.wantLength || got.Bounds().Dy() != tc.wantLength {
+				t.Errorf("Thumbnail() size = %v, want %dx%d", got.Bounds().Size(), tc.wantLength, tc.wantLength)
+			}
+
+			if c := got.RGBAAt(tc.wantLength/2, tc.wantLength/2); c != red {
+				t.Errorf("Thumbnail() color = %v, want %v", c, red)
+			}
+		})
+	}
+}
+
+func TestThumbnailCropsCenter(t *testing.T) {
+	// A 3x1 image keeps only its middle pixel.
+	img := image.NewRGBA(image.Rect(0, 0, 3, 1))
+	img.SetRGBA(0, 0, color.RGBA{R: 255, A: 255})
+	img.SetRGBA(1, 0, color.RGBA{G: 255, A: 255})
+	img.SetRGBA(2, 0, color.RGBA{B: 255, A: 255})
+
+	got := Thumbnail(img, 1)
+	if c := got.RGBAAt(0, 0); c != (color.RGBA{G: 255, A: 255}) {
+		t.Errorf("Thumbnail() color = %v, want green", c)
+	}
+}
+
+func TestResizeAverages(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.SetRGBA(0, 0, color.RGBA{R: 200, A: 255})
+	img.SetRGBA(1, 0, color.RGBA{R: 100, A: 255})
+	img.SetRGBA(0, 1, color.RGBA{R: 0, A: 255})
+	img.SetRGBA(1, 1, color.RGBA{R: 100, A: 255})
+
+	got := resize(img, 1, 1)
+	if c := got.RGBAAt(0, 0); c != (color.RGBA{R: 100, A: 255}) {
+		t.Errorf("resize() color = %v, want the average", c)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, solid(30, 20, color.RGBA{B: 255, A: 255})); err != nil {
+		t.Fatal(err)
+	}
+
+	img, format, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if format != "png" || img.Bounds().Dx() != 30 || img.Bounds().Dy() != 20 {
+		t.Errorf("Decode() = %s %v, want png 30x20", format, img.Bounds().Size())
+	}
+
+	if _, _, err := Decode(bytes.NewReader([]byte("not an image"))); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Decode() error = %v, want %v", err, ErrUnsupportedFormat)
+	}
+}
+
+func TestDecodeRejectsLargeDimensions(t *testing.T) {
+	// Only the header is read, the pixels never are.
+	var buf bytes.Buffer
+	png.Encode(&buf, solid(1, 1, color.RGBA{A: 255}))
+	data := buf.Bytes()
+
+	// Patch the IHDR width and height to 10000x10000 and its checksum.
+	copy(data[16:24], []byte{0, 0, 0x27, 0x10, 0, 0, 0x27, 0x10})
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+
+	if _, _, err := Decode(bytes.NewReader(data)); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Decode() error = %v, want %v", err, ErrTooLarge)
+	}
+}
+
+func TestEncodeStripsEXIF(t *testing.T) {
+	data := jpegWithOrientation(t, solid(2, 1, color.RGBA{R: 255, A: 255}), 6)
+
+	img, format, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	mimeType, err := Encode(&out, img, format)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	if mimeType != "image/jpeg" {
+		t.Errorf("Encode() mime type = %s, want image/jpeg", mimeType)
+	}
+
+	if bytes.Contains(out.Bytes(), []byte("Exif")) {
+		t.Errorf("Encode() kept the EXIF segment")
+	}
+}
@@ -0,0 +1,129 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+// Orientation returns the EXIF orientation of JPEG data, from 1 (upright) to
+// 8, or 1 when the image has none.
+func Orientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	// Walk the segments up to the start of the image data looking for the
+	// APP1 segment holding EXIF.
+	for offset := 2; offset+4 <= len(data); {
+		if data[offset] != 0xFF {
+			return 1
+		}
+
+		marker := data[offset+1]
+		if marker == 0xD9 || marker == 0xDA {
+			return 1
+		}
+
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		if length < 2 || offset+2+length > len(data) {
+			return 1
+		}
+
+		segment := data[offset+4 : offset+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+
+		offset += 2 + length
+	}
+
+	return 1
+}
+
+// exifOrientation reads tag 0x0112 from the first IFD of a TIFF structure.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	if order.Uint16(tiff[2:]) != 42 {
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := range entries {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+
+		// A SHORT value is stored in the first two bytes of the value field.
+		if order.Uint16(tiff[entry:]) == 0x0112 && order.Uint16(tiff[entry+2:]) == 3 {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+
+	return 1
+}
+
+// orient turns src upright for an EXIF orientation. Orientations 5 to 8 swap
+// the width and height.
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if orientation >= 5 {
+		width, height = height, width
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for dy := range height {
+		for dx := range width {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = width-1-dx, dy
+			case 3: // rotated 180°
+				sx, sy = width-1-dx, height-1-dy
+			case 4: // mirrored vertically
+				sx, sy = dx, height-1-dy
+			case 5: // transposed
+				sx, sy = dy, dx
+			case 6: // rotated 90° clockwise to display
+				sx, sy = dy, width-1-dx
+			case 7: // transversed
+				sx, sy = height-1-dy, width-1-dx
+			case 8: // rotated 90° counterclockwise to display
+				sx, sy = height-1-dy, dx
+			}
+
+			copy(dst.Pix[dst.PixOffset(dx, dy):dst.PixOffset(dx, dy)+4], src.Pix[src.PixOffset(bounds.Min.X+sx, bounds.Min.Y+sy):])
+		}
+	}
+
+	return dst
+}
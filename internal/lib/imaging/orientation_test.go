@@ -0,0 +1,112 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// jpegWithOrientation encodes img and inserts an EXIF segment holding only the
+// orientation tag.
+func jpegWithOrientation(t *testing.T, img image.Image, orientation uint16) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), app1...), data[2:]...)
+}
+
+func TestOrientation(t *testing.T) {
+	img := solid(2, 1, color.RGBA{A: 255})
+
+	for orientation := uint16(1); orientation <= 8; orientation++ {
+		if got := Orientation(jpegWithOrientation(t, img, orientation)); got != int(orientation) {
+			t.Errorf("Orientation() = %d, want %d", got, orientation)
+		}
+	}
+
+	var plain bytes.Buffer
+	jpeg.Encode(&plain, img, nil)
+
+	if got := Orientation(plain.Bytes()); got != 1 {
+		t.Errorf("Orientation() without EXIF = %d, want 1", got)
+	}
+
+	if got := Orientation([]byte("not a jpeg")); got != 1 {
+		t.Errorf("Orientation() of other data = %d, want 1", got)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// A 2x1 image with A on the left and B on the right.
+	a := color.RGBA{R: 255, A: 255}
+	b := color.RGBA{B: 255, A: 255}
+
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.SetRGBA(0, 0, a)
+	src.SetRGBA(1, 0, b)
+
+	tests := []struct {
+		orientation int
+		want        [][]color.RGBA // rows of the upright image
+	}{
+		{1, [][]color.RGBA{{a, b}}},
+		{2, [][]color.RGBA{{b, a}}},
+		{3, [][]color.RGBA{{b, a}}},
+		{4, [][]color.RGBA{{a, b}}},
+		{5, [][]color.RGBA{{a}, {b}}},
+		{6, [][]color.RGBA{{a}, {b}}},
+		{7, [][]color.RGBA{{b}, {a}}},
+		{8, [][]color.RGBA{{b}, {a}}},
+	}
+
+	for _, tc := range tests {
+		got := orient(src, tc.orientation)
+
+		if got.Bounds().Dy() != len(tc.want) || got.Bounds().Dx() != len(tc.want[0]) {
+			t.Errorf("orient(%d) size = %v", tc.orientation, got.Bounds().Size())
+			continue
+		}
+
+		for y, row := range tc.want {
+			for x, want := range row {
+				if c := got.RGBAAt(x, y); c != want {
+					t.Errorf("orient(%d) pixel %d,%d = %v, want %v", tc.orientation, x, y, c, want)
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeAppliesOrientation(t *testing.T) {
+	data := jpegWithOrientation(t, solid(40, 20, color.RGBA{G: 255, A: 255}), 6)
+
+	img, _, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if img.Bounds().Dx() != 20 || img.Bounds().Dy() != 40 {
+		t.Errorf("Decode() size = %v, want 20x40", img.Bounds().Size())
+	}
+}
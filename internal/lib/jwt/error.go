@@ -0,0 +1,15 @@
+package jwt
+
+import "errors"
+
+var (
+	ErrInvalidToken      = errors.New("token is invalid")
+	ErrTokenExpired      = errors.New("token has expired")
+	ErrTokenNotYetValid  = errors.New("token is not valid yet")
+	ErrInvalidIssuer     = errors.New("token issuer is invalid")
+	ErrInvalidAudience   = errors.New("token audience is invalid")
+	ErrUnknownKey        = errors.New("token was signed with an unknown key")
+	ErrNoSigningKey      = errors.New("key ring has no private key to sign with")
+	ErrUnsupportedKey    = errors.New("unsupported key type, use RSA, ECDSA P-256 or Ed25519")
+	ErrMissingExpiration = errors.New("token lifetime must be greater than 0")
+)
@@ -2,36 +2,134 @@ package jwt
 
 import (
 	"errors"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-func (j *JWT) ExtractToken(c *gin.Context) (string, error) {
-	contextQuery := c.Query("token")
-	if contextQuery != "" {
-		return contextQuery, nil
+// TokenSource is a part of the request a token can be read from.
+type TokenSource string
+
+const (
+	SourceHeader TokenSource = "header"
+	SourceCookie TokenSource = "cookie"
+	// SourceQuery ends up in access logs and proxy caches, so routes have to
+	// opt in with WithQuery.
+	SourceQuery TokenSource = "query"
+)
+
+// ExtractOptions select where ExtractToken looks for a token.
+type ExtractOptions struct {
+	// Sources are tried in order, the first one holding a token wins.
+	Sources      []TokenSource
+	CookieName   string
+	QueryParam   string
+	HeaderScheme string
+}
+
+type ExtractOption func(*ExtractOptions)
+
+// DefaultExtractOptions read the token cookie, then the Authorization header
+// with the Bearer scheme.
+func DefaultExtractOptions() ExtractOptions {
+	return ExtractOptions{
+		Sources:      []TokenSource{SourceCookie, SourceHeader},
+		CookieName:   "token",
+		QueryParam:   "token",
+		HeaderScheme: "Bearer",
 	}
+}
 
-	contextCookie, _ := c.Cookie("token")
-	if contextCookie != "" {
-		return contextCookie, nil
+// WithSources replaces the sources and their precedence.
+func WithSources(sources ...TokenSource) ExtractOption {
+	return func(o *ExtractOptions) {
+		o.Sources = sources
 	}
+}
 
-	contextHeader := c.GetHeader("Authorization")
-	if contextHeader != "" {
-		// Bearer <token>
-		parts := strings.Split(contextHeader, " ")
-		if len(parts) != 2 {
-			return "", errors.New("invalid token format")
-		}
+// WithCookie reads the token from the cookie named name.
+func WithCookie(name string) ExtractOption {
+	return func(o *ExtractOptions) {
+		o.CookieName = name
+	}
+}
 
-		if parts[0] != "Bearer" || parts[1] == "" {
-			return "", errors.New("invalid token format")
+// WithHeaderScheme expects the Authorization header to use scheme.
+func WithHeaderScheme(scheme string) ExtractOption {
+	return func(o *ExtractOptions) {
+		o.HeaderScheme = scheme
+	}
+}
+
+// WithQuery also accepts the token from the query parameter param, after the
+// other sources. Only use it for links that cannot carry a header, such as
+// file downloads.
+func WithQuery(param string) ExtractOption {
+	return func(o *ExtractOptions) {
+		o.QueryParam = param
+		if !slices.Contains(o.Sources, SourceQuery) {
+			o.Sources = append(slices.Clone(o.Sources), SourceQuery)
 		}
+	}
+}
+
+// ExtractToken returns the token of the request from the first source of the
+// options holding one, DefaultExtractOptions without options.
+func (j *JWT) ExtractToken(c *gin.Context, opts ...ExtractOption) (string, error) {
+	token, _, err := j.ExtractTokenSource(c, opts...)
+	return token, err
+}
+
+// ExtractTokenSource is ExtractToken that also returns the source the token
+// was read from, so callers can apply source specific checks such as CSRF.
+func (j *JWT) ExtractTokenSource(c *gin.Context, opts ...ExtractOption) (string, TokenSource, error) {
+	options := DefaultExtractOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	for _, source := range options.Sources {
+		switch source {
+		case SourceQuery:
+			if contextQuery := c.Query(options.QueryParam); contextQuery != "" {
+				return contextQuery, source, nil
+			}
+
+		case SourceCookie:
+			if contextCookie, _ := c.Cookie(options.CookieName); contextCookie != "" {
+				return contextCookie, source, nil
+			}
 
-		return parts[1], nil
+		case SourceHeader:
+			contextHeader := c.GetHeader("Authorization")
+			if contextHeader == "" {
+				continue
+			}
+
+			// <scheme> <token>
+			parts := strings.Split(contextHeader, " ")
+			if len(parts) != 2 {
+				return "", source, errors.New("invalid token format")
+			}
+
+			if !strings.EqualFold(parts[0], options.HeaderScheme) || parts[1] == "" {
+				return "", source, errors.New("invalid token format")
+			}
+
+			return parts[1], source, nil
+		}
 	}
 
-	return "", errors.New("token not found")
+	return "", "", errors.New("token not found")
+}
+
+// APIKeyHeader carries API keys. It is kept apart from the Authorization
+// header so a key is never parsed as a token.
+const APIKeyHeader = "X-API-Key"
+
+// ExtractAPIKey returns the API key sent with the request, if any.
+func (j *JWT) ExtractAPIKey(c *gin.Context) (string, bool) {
+	apiKey := strings.TrimSpace(c.GetHeader(APIKeyHeader))
+	return apiKey, apiKey != ""
 }
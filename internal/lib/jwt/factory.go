@@ -2,8 +2,22 @@ package jwt
 
 import "gintama/internal/config"
 
-func New(config *config.ConfigApp) *JWT {
+// New returns a JWT signer. With a key ring tokens are signed with its
+// signing key, the HS256 secret is still accepted when verifying so tokens
+// issued before switching keep working until they expire.
+func New(config *config.ConfigApp, keys *KeyRing) *JWT {
 	return &JWT{
 		config: config,
+		keys:   keys,
 	}
 }
+
+// JWKS returns the public keys other services verify tokens with, empty
+// when tokens are signed with the shared secret.
+func (j *JWT) JWKS() JWKS {
+	if j.keys == nil {
+		return JWKS{Keys: []JWK{}}
+	}
+
+	return j.keys.JWKS()
+}
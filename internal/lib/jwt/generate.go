@@ -1,32 +1,62 @@
 package jwt
 
 import (
-	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-func (j *JWT) Generate(payload *JWTPayload) (string, int64, error) {
-	expiresIn, err := strconv.Atoi(payload.ExpiresAt)
-	if err != nil {
-		return "", 0, err
+func (j *JWT) Generate(payload *JWTPayload) (string, *JWTClaims, error) {
+	if payload.ExpiresIn <= 0 {
+		return "", nil, ErrMissingExpiration
 	}
 
-	secretKey := []byte(j.config.JWTSecret)
-	ExpiresToken := time.Now().Add(time.Hour * 24 * time.Duration(expiresIn)).Unix()
+	now := time.Now()
+
+	claims := &JWTClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.Must(uuid.NewV7()).String(),
+			Issuer:    j.config.Name,
+			Subject:   payload.UID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(payload.ExpiresIn)),
+		},
+		SessionID: payload.SessionID,
+		Role:      payload.Role,
+		Scopes:    payload.Scopes,
+		Purpose:   payload.Purpose,
+	}
 
-	claims := jwt.MapClaims{
-		"exp": ExpiresToken,
-		"iss": j.config.Name,
-		"uid": payload.UID,
+	if audience := j.audience(); audience != "" {
+		claims.Audience = jwt.ClaimStrings{audience}
 	}
 
+	var signingKey any = []byte(j.config.JWTSecret)
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	t, err := token.SignedString(secretKey)
+
+	if j.keys != nil {
+		key := j.keys.SigningKey()
+		signingKey = key.Private
+		token = jwt.NewWithClaims(key.Method, claims)
+		token.Header["kid"] = key.ID
+	}
+
+	t, err := token.SignedString(signingKey)
 	if err != nil {
-		return "", 0, err
+		return "", nil, err
+	}
+
+	return t, claims, nil
+}
+
+// audience is the API the tokens are meant for, the server URL unless a
+// dedicated audience is configured.
+func (j *JWT) audience() string {
+	if j.config.JWTAudience != "" {
+		return j.config.JWTAudience
 	}
 
-	return t, ExpiresToken, nil
+	return j.config.ServerURL
 }
@@ -1,6 +1,7 @@
 package jwt
 
 import (
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -16,7 +17,7 @@ func TestGenerate(t *testing.T) {
 		name          string
 		config        *config.ConfigApp
 		payload       *JWTPayload
-		wantErr       bool
+		wantErr       error
 		validateToken bool
 	}{
 		{
@@ -27,62 +28,48 @@ func TestGenerate(t *testing.T) {
 			},
 			payload: &JWTPayload{
 				UID:       uuid.New().String(),
-				ExpiresAt: "7",
+				ExpiresIn: 7 * 24 * time.Hour,
 			},
-			wantErr:       false,
 			validateToken: true,
 		},
 		{
-			name: "Valid token generation with 1 day expiry",
+			name: "Valid token generation with session, role and scopes",
 			config: &config.ConfigApp{
 				Name:      "gintama",
 				JWTSecret: "test-secret-key-12345",
+				ServerURL: "https://api.example.com",
 			},
 			payload: &JWTPayload{
 				UID:       uuid.New().String(),
-				ExpiresAt: "1",
+				SessionID: uuid.New().String(),
+				Role:      uuid.New().String(),
+				Scopes:    []string{"users:read"},
+				ExpiresIn: 15 * time.Minute,
 			},
-			wantErr:       false,
 			validateToken: true,
 		},
 		{
-			name: "Valid token generation with 30 days expiry",
+			name: "Missing lifetime",
 			config: &config.ConfigApp{
 				Name:      "gintama",
 				JWTSecret: "test-secret-key-12345",
 			},
 			payload: &JWTPayload{
-				UID:       uuid.New().String(),
-				ExpiresAt: "30",
+				UID: uuid.New().String(),
 			},
-			wantErr:       false,
-			validateToken: true,
+			wantErr: ErrMissingExpiration,
 		},
 		{
-			name: "Invalid ExpiresAt - not a number",
+			name: "Negative lifetime",
 			config: &config.ConfigApp{
 				Name:      "gintama",
 				JWTSecret: "test-secret-key-12345",
 			},
 			payload: &JWTPayload{
 				UID:       uuid.New().String(),
-				ExpiresAt: "invalid",
+				ExpiresIn: -time.Hour,
 			},
-			wantErr:       true,
-			validateToken: false,
-		},
-		{
-			name: "Empty UID",
-			config: &config.ConfigApp{
-				Name:      "gintama",
-				JWTSecret: "test-secret-key-12345",
-			},
-			payload: &JWTPayload{
-				UID:       "",
-				ExpiresAt: "7",
-			},
-			wantErr:       false,
-			validateToken: true,
+			wantErr: ErrMissingExpiration,
 		},
 	}
 
@@ -90,22 +77,17 @@ func TestGenerate(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			j := &JWT{config: tc.config}
 
-			token, expiresAt, err := j.Generate(tc.payload)
+			token, claims, err := j.Generate(tc.payload)
 
-			if (err != nil) != tc.wantErr {
+			if !errors.Is(err, tc.wantErr) {
 				t.Errorf("Generate() error = %v, wantErr %v", err, tc.wantErr)
 				return
 			}
 
-			if tc.wantErr {
+			if tc.wantErr != nil {
 				return
 			}
 
-			// Verify token is not empty
-			if token == "" {
-				t.Error("Generate() returned empty token")
-			}
-
 			// Verify token format (should have 3 parts separated by dots)
 			parts := strings.Split(token, ".")
 			if len(parts) != 3 {
@@ -113,48 +95,58 @@ func TestGenerate(t *testing.T) {
 			}
 
 			// Verify expiresAt is in the future
-			if expiresAt <= time.Now().Unix() {
+			if !claims.ExpiresAt.After(time.Now()) {
 				t.Error("Generate() expiresAt should be in the future")
 			}
 
-			// Validate token if required
-			if tc.validateToken {
-				parsedToken, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
-					return []byte(tc.config.JWTSecret), nil
-				})
-				if err != nil {
-					t.Errorf("Generate() produced invalid token: %v", err)
-				}
-
-				if !parsedToken.Valid {
-					t.Error("Generate() produced invalid token")
-				}
-
-				// Verify claims
-				if claims, ok := parsedToken.Claims.(jwt.MapClaims); ok {
-					// Check UID
-					if uid, ok := claims["uid"].(string); !ok || uid != tc.payload.UID {
-						t.Errorf("Generate() token UID = %v, want %v", claims["uid"], tc.payload.UID)
-					}
-
-					// Check issuer
-					if iss, ok := claims["iss"].(string); !ok || iss != tc.config.Name {
-						t.Errorf("Generate() token issuer = %v, want %v", claims["iss"], tc.config.Name)
-					}
-
-					// Check expiration
-					if exp, ok := claims["exp"].(float64); !ok || int64(exp) != expiresAt {
-						t.Errorf("Generate() token exp = %v, want %v", claims["exp"], expiresAt)
-					}
-				} else {
-					t.Error("Generate() token claims are invalid")
-				}
+			if !tc.validateToken {
+				return
+			}
+
+			parsed := &JWTClaims{}
+			_, err = jwt.ParseWithClaims(token, parsed, func(t *jwt.Token) (interface{}, error) {
+				return []byte(tc.config.JWTSecret), nil
+			})
+			if err != nil {
+				t.Fatalf("Generate() produced invalid token: %v", err)
+			}
+
+			if parsed.Subject != tc.payload.UID {
+				t.Errorf("Generate() token sub = %v, want %v", parsed.Subject, tc.payload.UID)
+			}
+
+			if parsed.Issuer != tc.config.Name {
+				t.Errorf("Generate() token iss = %v, want %v", parsed.Issuer, tc.config.Name)
+			}
+
+			if tc.config.ServerURL != "" && (len(parsed.Audience) != 1 || parsed.Audience[0] != tc.config.ServerURL) {
+				t.Errorf("Generate() token aud = %v, want [%v]", parsed.Audience, tc.config.ServerURL)
+			}
+
+			if parsed.ID == "" || parsed.ID != claims.ID {
+				t.Errorf("Generate() token jti = %v, want %v", parsed.ID, claims.ID)
+			}
+
+			if parsed.IssuedAt == nil || parsed.NotBefore == nil {
+				t.Error("Generate() token should carry iat and nbf")
+			}
+
+			if !parsed.ExpiresAt.Equal(claims.ExpiresAt.Time) {
+				t.Errorf("Generate() token exp = %v, want %v", parsed.ExpiresAt, claims.ExpiresAt)
+			}
+
+			if parsed.SessionID != tc.payload.SessionID || parsed.Role != tc.payload.Role {
+				t.Errorf("Generate() token sid/role = %v/%v, want %v/%v", parsed.SessionID, parsed.Role, tc.payload.SessionID, tc.payload.Role)
+			}
+
+			if strings.Join(parsed.Scopes, " ") != strings.Join(tc.payload.Scopes, " ") {
+				t.Errorf("Generate() token scopes = %v, want %v", parsed.Scopes, tc.payload.Scopes)
 			}
 		})
 	}
 }
 
-func TestGenerateTokenExpiry(t *testing.T) {
+func TestGenerateExpiresIn(t *testing.T) {
 	j := &JWT{
 		config: &config.ConfigApp{
 			Name:      "gofi",
@@ -164,34 +156,55 @@ func TestGenerateTokenExpiry(t *testing.T) {
 
 	tests := []struct {
 		name      string
-		expiresAt string
-		wantDays  int
+		expiresIn time.Duration
 	}{
-		{"1 day expiry", "1", 1},
-		{"7 days expiry", "7", 7},
-		{"30 days expiry", "30", 30},
+		{"15 minutes expiry", 15 * time.Minute},
+		{"1 day expiry", 24 * time.Hour},
+		{"30 days expiry", 30 * 24 * time.Hour},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			payload := &JWTPayload{
+			_, claims, err := j.Generate(&JWTPayload{
 				UID:       uuid.New().String(),
-				ExpiresAt: tc.expiresAt,
-			}
-
-			_, expiresAt, err := j.Generate(payload)
+				ExpiresIn: tc.expiresIn,
+			})
 			if err != nil {
 				t.Fatalf("Generate() error = %v", err)
 			}
 
-			// Calculate expected expiry time
-			expectedExpiry := time.Now().Add(time.Hour * 24 * time.Duration(tc.wantDays)).Unix()
+			expectedExpiry := time.Now().Add(tc.expiresIn).Unix()
 
 			// Allow 2 seconds tolerance for test execution time
-			diff := expiresAt - expectedExpiry
+			diff := claims.ExpiresAt.Unix() - expectedExpiry
 			if diff < -2 || diff > 2 {
-				t.Errorf("Generate() expiresAt = %v, want approximately %v (diff: %v seconds)", expiresAt, expectedExpiry, diff)
+				t.Errorf("Generate() expiresAt = %v, want approximately %v (diff: %v seconds)", claims.ExpiresAt.Unix(), expectedExpiry, diff)
 			}
 		})
 	}
 }
+
+func TestGenerateUniqueID(t *testing.T) {
+	j := &JWT{
+		config: &config.ConfigApp{
+			Name:      "gofi",
+			JWTSecret: "test-secret-key-12345",
+		},
+	}
+
+	payload := &JWTPayload{UID: uuid.New().String(), ExpiresIn: time.Hour}
+
+	_, first, err := j.Generate(payload)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	_, second, err := j.Generate(payload)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if first.ID == second.ID {
+		t.Errorf("Generate() returned the same jti %v twice", first.ID)
+	}
+}
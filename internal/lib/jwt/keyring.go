@@ -0,0 +1,219 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSAKeyBits rejects RSA keys too short to be trusted for signing.
+const minRSAKeyBits = 2048
+
+// Key is a single entry of the key ring. Retired keys only carry the public
+// half and are kept so tokens they signed stay valid until they expire.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// KeyRing holds every key tokens are verified with and the one new tokens
+// are signed with.
+type KeyRing struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+// NewKeyRing builds a key ring from keys, signing with the key signingKeyID.
+// An empty signingKeyID picks the private key whose ID sorts last, so dated
+// file names such as 2024-06-01.pem roll over on their own.
+func NewKeyRing(signingKeyID string, keys ...*Key) (*KeyRing, error) {
+	ring := &KeyRing{keys: make(map[string]*Key, len(keys))}
+
+	for _, key := range keys {
+		if _, ok := ring.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+
+		ring.keys[key.ID] = key
+
+		if key.Private == nil {
+			continue
+		}
+
+		if signingKeyID == "" && (ring.signing == nil || key.ID > ring.signing.ID) {
+			ring.signing = key
+		}
+	}
+
+	if signingKeyID != "" {
+		key, ok := ring.keys[signingKeyID]
+		if !ok || key.Private == nil {
+			return nil, fmt.Errorf("signing key %q: %w", signingKeyID, ErrNoSigningKey)
+		}
+
+		ring.signing = key
+	}
+
+	if ring.signing == nil {
+		return nil, ErrNoSigningKey
+	}
+
+	return ring, nil
+}
+
+// LoadKeyRing reads every .pem file in dir. The file name without extension
+// is the key ID, private keys can sign and public keys only verify.
+func LoadKeyRing(dir string, signingKeyID string) (*KeyRing, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+
+	keys := make([]*Key, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := ParseKey(strings.TrimSuffix(filepath.Base(path), ".pem"), data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return NewKeyRing(signingKeyID, keys...)
+}
+
+// ParseKey decodes a PEM encoded private key (PKCS #8, PKCS #1 or SEC 1) or
+// public key (PKIX) and picks the signing method from its type.
+func ParseKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var parsed any
+	var err error
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unexpected PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{ID: id}
+
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.Private = signer
+		key.Public = signer.Public()
+	} else {
+		key.Public = parsed
+	}
+
+	switch public := key.Public.(type) {
+	case *rsa.PublicKey:
+		if public.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key must be at least %d bits", minRSAKeyBits)
+		}
+		key.Method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		if public.Curve != elliptic.P256() {
+			return nil, ErrUnsupportedKey
+		}
+		key.Method = jwt.SigningMethodES256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	return key, nil
+}
+
+// SigningKey returns the key new tokens are signed with.
+func (r *KeyRing) SigningKey() *Key {
+	return r.signing
+}
+
+// Get returns the key with the given ID.
+func (r *KeyRing) Get(id string) (*Key, bool) {
+	key, ok := r.keys[id]
+	return key, ok
+}
+
+// JWKS returns the public keys as a JSON Web Key Set, ordered by key ID.
+func (r *KeyRing) JWKS() JWKS {
+	ids := make([]string, 0, len(r.keys))
+	for id := range r.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	set := JWKS{Keys: make([]JWK, 0, len(ids))}
+	for _, id := range ids {
+		set.Keys = append(set.Keys, r.keys[id].JWK())
+	}
+
+	return set
+}
+
+// JWK returns the public half of the key as a JSON Web Key.
+func (k *Key) JWK() JWK {
+	jwk := JWK{
+		Kid: k.ID,
+		Use: "sig",
+		Alg: k.Method.Alg(),
+	}
+
+	switch public := k.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case *ecdsa.PublicKey:
+		// Uncompressed point: 0x04 || X || Y, each padded to the curve size.
+		point, _ := public.ECDH()
+		raw := point.Bytes()[1:]
+		jwk.Kty = "EC"
+		jwk.Crv = public.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(raw[:len(raw)/2])
+		jwk.Y = base64.RawURLEncoding.EncodeToString(raw[len(raw)/2:])
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+
+	return jwk
+}
@@ -0,0 +1,230 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gintama/internal/config"
+
+	"github.com/google/uuid"
+)
+
+func writePrivateKey(t *testing.T, dir string, id string, key any) {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, id+".pem"), data, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func writePublicKey(t *testing.T, dir string, id string, key any) {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey() error = %v", err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, id+".pem"), data, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func TestKeyRingSignAndVerify(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		name    string
+		key     any
+		wantAlg string
+		wantKty string
+	}{
+		{"RSA signs with RS256", rsaKey, "RS256", "RSA"},
+		{"ECDSA P-256 signs with ES256", ecKey, "ES256", "EC"},
+		{"Ed25519 signs with EdDSA", edKey, "EdDSA", "OKP"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writePrivateKey(t, dir, "key-1", tc.key)
+
+			ring, err := LoadKeyRing(dir, "")
+			if err != nil {
+				t.Fatalf("LoadKeyRing() error = %v", err)
+			}
+
+			j := New(&config.ConfigApp{Name: "gintama"}, ring)
+
+			uid := uuid.New().String()
+			token, _, err := j.Generate(&JWTPayload{UID: uid, ExpiresIn: time.Hour})
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			claims, err := j.Verify(token)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+
+			if claims.Subject != uid {
+				t.Errorf("Verify() claims.Subject = %v, want %v", claims.Subject, uid)
+			}
+
+			jwks := j.JWKS()
+			if len(jwks.Keys) != 1 {
+				t.Fatalf("JWKS() returned %d keys, want 1", len(jwks.Keys))
+			}
+
+			jwk := jwks.Keys[0]
+			if jwk.Kid != "key-1" || jwk.Alg != tc.wantAlg || jwk.Kty != tc.wantKty {
+				t.Errorf("JWKS() key = %+v, want kid key-1, alg %s, kty %s", jwk, tc.wantAlg, tc.wantKty)
+			}
+		})
+	}
+}
+
+func TestKeyRingRotation(t *testing.T) {
+	oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	dir := t.TempDir()
+	writePrivateKey(t, dir, "2024-06-01", oldKey)
+
+	oldRing, err := LoadKeyRing(dir, "")
+	if err != nil {
+		t.Fatalf("LoadKeyRing() error = %v", err)
+	}
+
+	oldToken, _, err := New(&config.ConfigApp{}, oldRing).Generate(&JWTPayload{UID: "uid", ExpiresIn: time.Hour})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	// The old key is retired to its public half and a newer key takes over.
+	writePublicKey(t, dir, "2024-06-01", &oldKey.PublicKey)
+	writePrivateKey(t, dir, "2024-12-01", newKey)
+
+	ring, err := LoadKeyRing(dir, "")
+	if err != nil {
+		t.Fatalf("LoadKeyRing() error = %v", err)
+	}
+
+	if got := ring.SigningKey().ID; got != "2024-12-01" {
+		t.Errorf("SigningKey().ID = %v, want 2024-12-01", got)
+	}
+
+	j := New(&config.ConfigApp{}, ring)
+
+	if _, err := j.Verify(oldToken); err != nil {
+		t.Errorf("Verify() token signed by retired key error = %v", err)
+	}
+
+	newToken, _, err := j.Generate(&JWTPayload{UID: "uid", ExpiresIn: time.Hour})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if _, err := New(&config.ConfigApp{}, oldRing).Verify(newToken); err == nil {
+		t.Error("Verify() with a ring missing the new key should fail")
+	}
+
+	if got := len(j.JWKS().Keys); got != 2 {
+		t.Errorf("JWKS() returned %d keys, want 2", got)
+	}
+}
+
+func TestKeyRingSigningKeyID(t *testing.T) {
+	first, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	second, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	dir := t.TempDir()
+	writePrivateKey(t, dir, "a", first)
+	writePrivateKey(t, dir, "b", second)
+	writePublicKey(t, dir, "c", &second.PublicKey)
+
+	ring, err := LoadKeyRing(dir, "a")
+	if err != nil {
+		t.Fatalf("LoadKeyRing() error = %v", err)
+	}
+
+	if got := ring.SigningKey().ID; got != "a" {
+		t.Errorf("SigningKey().ID = %v, want a", got)
+	}
+
+	if _, err := LoadKeyRing(dir, "c"); !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("LoadKeyRing() with public signing key error = %v, want %v", err, ErrNoSigningKey)
+	}
+
+	if _, err := LoadKeyRing(dir, "missing"); !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("LoadKeyRing() with unknown signing key error = %v, want %v", err, ErrNoSigningKey)
+	}
+}
+
+func TestKeyRingRejectsKeys(t *testing.T) {
+	smallRSA, _ := rsa.GenerateKey(rand.Reader, 1024)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+
+	tests := []struct {
+		name string
+		key  any
+	}{
+		{"RSA key shorter than 2048 bits", smallRSA},
+		{"ECDSA key on P-384", p384},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writePrivateKey(t, dir, "key", tc.key)
+
+			if _, err := LoadKeyRing(dir, ""); err == nil {
+				t.Error("LoadKeyRing() expected error but got nil")
+			}
+		})
+	}
+}
+
+func TestVerifySecretTokenWithKeyRing(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	dir := t.TempDir()
+	writePrivateKey(t, dir, "key-1", key)
+
+	ring, err := LoadKeyRing(dir, "")
+	if err != nil {
+		t.Fatalf("LoadKeyRing() error = %v", err)
+	}
+
+	secretToken, _, err := New(&config.ConfigApp{JWTSecret: "test-secret"}, nil).Generate(&JWTPayload{UID: "uid", ExpiresIn: time.Hour})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	// Tokens issued before switching to keys stay valid while the secret is set.
+	if _, err := New(&config.ConfigApp{JWTSecret: "test-secret"}, ring).Verify(secretToken); err != nil {
+		t.Errorf("Verify() with secret still configured error = %v", err)
+	}
+
+	if _, err := New(&config.ConfigApp{}, ring).Verify(secretToken); err == nil {
+		t.Error("Verify() with secret removed should fail")
+	}
+}
@@ -1,19 +1,57 @@
 package jwt
 
-import "gintama/internal/config"
+import (
+	"time"
+
+	"gintama/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
 
 type JWT struct {
 	config *config.ConfigApp
+	keys   *KeyRing // nil signs and verifies with the HS256 secret only
 }
 
 type JWTPayload struct {
 	UID       string
-	Secret    string
-	ExpiresAt string
+	SessionID string
+	Role      string
+	Scopes    []string
+	ExpiresIn time.Duration
+	Purpose   string // restricts what the token is accepted for, empty for access tokens
 }
 
+// JWTClaims are the claims of every token the API issues. The subject is the
+// user ID and the ID (jti) is unique per token.
 type JWTClaims struct {
-	Exp int64
-	Iss string
-	UID string
+	jwt.RegisteredClaims
+	SessionID string   `json:"sid,omitempty"`
+	Role      string   `json:"role,omitempty"`
+	Scopes    []string `json:"scopes,omitempty"`
+	Purpose   string   `json:"pur,omitempty"`
+}
+
+// PurposeTwoFactor marks the challenge token issued by sign in when the user
+// still has to provide a second factor.
+const PurposeTwoFactor = "2fa"
+
+// PurposeVerifyAccount marks the token embedded in the email verification link.
+const PurposeVerifyAccount = "verify"
+
+// JWKS is a JSON Web Key Set as served from /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
 }
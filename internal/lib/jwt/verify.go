@@ -7,30 +7,65 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Verify checks the signature, issuer, audience and validity period of the
+// token, allowing the configured leeway for clock skew between servers.
 func (j *JWT) Verify(extractToken string) (*JWTClaims, error) {
-	token, err := jwt.Parse(extractToken, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Method.Alg())
-		}
-		return []byte(j.config.JWTSecret), nil
-	})
+	options := []jwt.ParserOption{
+		jwt.WithIssuer(j.config.Name),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(j.config.JWTLeeway),
+	}
+
+	if audience := j.audience(); audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
 
+	claims := &JWTClaims{}
+	token, err := jwt.ParseWithClaims(extractToken, claims, j.keyFunc, options...)
 	if err != nil {
-		message := fmt.Sprintf("Token verification failed: %v. Please ensure your token is valid and not expired.", err)
-		return nil, errors.New(message)
+		switch {
+		case errors.Is(err, jwt.ErrTokenExpired):
+			return nil, ErrTokenExpired
+		case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+			return nil, ErrTokenNotYetValid
+		case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+			return nil, ErrInvalidIssuer
+		case errors.Is(err, jwt.ErrTokenInvalidAudience):
+			return nil, ErrInvalidAudience
+		case errors.Is(err, ErrUnknownKey):
+			return nil, ErrUnknownKey
+		default:
+			return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		}
+	}
+
+	if !token.Valid || claims.Subject == "" {
+		return nil, ErrInvalidToken
 	}
 
-	if !token.Valid {
-		return nil, errors.New("invalid token")
+	return claims, nil
+}
+
+// keyFunc picks the verification key from the kid header. Tokens without a
+// kid were signed with the HS256 secret.
+func (j *JWT) keyFunc(t *jwt.Token) (interface{}, error) {
+	if kid, ok := t.Header["kid"].(string); ok && j.keys != nil {
+		key, ok := j.keys.Get(kid)
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+
+		if t.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Method.Alg())
+		}
+
+		return key.Public, nil
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return &JWTClaims{
-			Exp: int64(claims["exp"].(float64)),
-			Iss: claims["iss"].(string),
-			UID: claims["uid"].(string),
-		}, nil
+	if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok || j.config.JWTSecret == "" {
+		return nil, fmt.Errorf("unexpected signing method: %v", t.Method.Alg())
 	}
 
-	return nil, errors.New("invalid token")
+	return []byte(j.config.JWTSecret), nil
 }
@@ -0,0 +1,19 @@
+package lockout
+
+import "strings"
+
+// EmailKey returns the key tracking failures against an account.
+func EmailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// IPKey returns the key tracking failures from a client address.
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// ChallengeKey returns the key tracking failures against a single two-factor
+// challenge, identified by the jti of its token.
+func ChallengeKey(jti string) string {
+	return "challenge:" + jti
+}
@@ -0,0 +1,21 @@
+package lockout
+
+import "testing"
+
+func TestEmailKey(t *testing.T) {
+	if got, want := EmailKey("  John.Doe@Example.com "), "email:john.doe@example.com"; got != want {
+		t.Errorf("EmailKey() = %q, want %q", got, want)
+	}
+}
+
+func TestIPKey(t *testing.T) {
+	if got, want := IPKey("203.0.113.7"), "ip:203.0.113.7"; got != want {
+		t.Errorf("IPKey() = %q, want %q", got, want)
+	}
+}
+
+func TestChallengeKey(t *testing.T) {
+	if got, want := ChallengeKey("0198a1b2-c3d4-7e5f-8a9b-0c1d2e3f4a5b"), "challenge:0198a1b2-c3d4-7e5f-8a9b-0c1d2e3f4a5b"; got != want {
+		t.Errorf("ChallengeKey() = %q, want %q", got, want)
+	}
+}
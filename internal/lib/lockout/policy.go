@@ -0,0 +1,54 @@
+package lockout
+
+import "time"
+
+// Delay returns the wait required after the given number of failures.
+func (p *Policy) Delay(failures int) time.Duration {
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < failures; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+
+	return min(delay, p.MaxDelay)
+}
+
+// ShouldLock reports whether the failures reached the lockout threshold.
+func (p *Policy) ShouldLock(failures int) bool {
+	return p.MaxAttempts > 0 && failures >= p.MaxAttempts
+}
+
+// LockedUntil returns when a lockout starting at now ends.
+func (p *Policy) LockedUntil(now time.Time) time.Time {
+	return now.Add(p.Duration)
+}
+
+// WindowStart returns the oldest failure time still counted at now.
+func (p *Policy) WindowStart(now time.Time) time.Time {
+	return now.Add(-p.Window)
+}
+
+// RetryAfter returns how long the caller must wait before the next attempt
+// and whether the wait is caused by a lockout rather than a progressive delay.
+func (p *Policy) RetryAfter(state State, now time.Time) (time.Duration, bool) {
+	if state.LockedUntil != nil && state.LockedUntil.After(now) {
+		return state.LockedUntil.Sub(now), true
+	}
+
+	// Failures that fell out of the window no longer slow the caller down.
+	if state.LastFailedAt.Before(p.WindowStart(now)) {
+		return 0, false
+	}
+
+	if wait := state.LastFailedAt.Add(p.Delay(state.Failures)).Sub(now); wait > 0 {
+		return wait, false
+	}
+
+	return 0, false
+}
@@ -0,0 +1,106 @@
+package lockout
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDelay(t *testing.T) {
+	policy := New(10, 15*time.Minute, 15*time.Minute)
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 0, want: 0},
+		{failures: 3, want: 0},
+		{failures: 4, want: time.Second},
+		{failures: 5, want: 2 * time.Second},
+		{failures: 6, want: 4 * time.Second},
+		{failures: 8, want: 16 * time.Second},
+		{failures: 9, want: 30 * time.Second},
+		{failures: 100, want: 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Delay(tt.failures); got != tt.want {
+			t.Errorf("Delay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestShouldLock(t *testing.T) {
+	policy := New(5, 15*time.Minute, 15*time.Minute)
+
+	if policy.ShouldLock(4) {
+		t.Error("ShouldLock(4) = true, want false")
+	}
+
+	if !policy.ShouldLock(5) {
+		t.Error("ShouldLock(5) = false, want true")
+	}
+
+	policy.MaxAttempts = 0
+	if policy.ShouldLock(100) {
+		t.Error("ShouldLock() with MaxAttempts 0 = true, want false")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	policy := New(5, 15*time.Minute, 15*time.Minute)
+
+	lockedUntil := now.Add(10 * time.Minute)
+	expiredLock := now.Add(-time.Minute)
+
+	tests := []struct {
+		name       string
+		state      State
+		wantWait   time.Duration
+		wantLocked bool
+	}{
+		{
+			name:  "No failures",
+			state: State{},
+		},
+		{
+			name:  "Free attempts",
+			state: State{Failures: 3, LastFailedAt: now},
+		},
+		{
+			name:     "Progressive delay",
+			state:    State{Failures: 5, LastFailedAt: now.Add(-500 * time.Millisecond)},
+			wantWait: 1500 * time.Millisecond,
+		},
+		{
+			name:  "Delay elapsed",
+			state: State{Failures: 5, LastFailedAt: now.Add(-5 * time.Second)},
+		},
+		{
+			name:       "Locked",
+			state:      State{Failures: 5, LastFailedAt: now, LockedUntil: &lockedUntil},
+			wantWait:   10 * time.Minute,
+			wantLocked: true,
+		},
+		{
+			name:  "Lock expired",
+			state: State{Failures: 0, LastFailedAt: now.Add(-20 * time.Minute), LockedUntil: &expiredLock},
+		},
+		{
+			name:  "Failures outside the window",
+			state: State{Failures: 4, LastFailedAt: now.Add(-16 * time.Minute)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait, locked := policy.RetryAfter(tt.state, now)
+			if wait != tt.wantWait {
+				t.Errorf("RetryAfter() wait = %v, want %v", wait, tt.wantWait)
+			}
+			if locked != tt.wantLocked {
+				t.Errorf("RetryAfter() locked = %v, want %v", locked, tt.wantLocked)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package lockout
+
+import "time"
+
+// Policy decides how long a caller must wait after failed sign in attempts.
+// The first FreeAttempts failures are not delayed, later ones wait BaseDelay
+// doubled per failure up to MaxDelay, and MaxAttempts failures within Window
+// lock the key for Duration.
+type Policy struct {
+	MaxAttempts  int
+	Window       time.Duration
+	Duration     time.Duration
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+}
+
+// State is the failed attempt history tracked for a single key, such as an
+// email address or an IP address.
+type State struct {
+	Failures     int
+	LastFailedAt time.Time
+	LockedUntil  *time.Time
+}
+
+func New(maxAttempts int, window time.Duration, duration time.Duration) *Policy {
+	return &Policy{
+		MaxAttempts:  maxAttempts,
+		Window:       window,
+		Duration:     duration,
+		FreeAttempts: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     30 * time.Second,
+	}
+}
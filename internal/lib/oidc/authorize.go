@@ -0,0 +1,32 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+func (c *Client) AuthCodeURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error) {
+	discovery, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(discovery.AuthorizationEndpoint)
+	if err != nil {
+		return "", err
+	}
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", c.config.ClientID)
+	query.Set("redirect_uri", c.config.RedirectURL)
+	query.Set("scope", strings.Join(c.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+
+	return authURL.String(), nil
+}
@@ -0,0 +1,88 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestAuthCodeURL(t *testing.T) {
+	provider := newFakeProvider(t)
+	client := provider.client(time.Now())
+
+	raw, err := client.AuthCodeURL(context.Background(), "state-1", "nonce-1", "challenge-1")
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+
+	authURL, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+
+	if got, want := authURL.Scheme+"://"+authURL.Host+authURL.Path, provider.server.URL+"/authorize"; got != want {
+		t.Errorf("AuthCodeURL() endpoint = %q, want %q", got, want)
+	}
+
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"scope":                 "openid email profile",
+		"state":                 "state-1",
+		"nonce":                 "nonce-1",
+		"code_challenge":        "challenge-1",
+		"code_challenge_method": "S256",
+	}
+
+	query := authURL.Query()
+	for key, value := range want {
+		if got := query.Get(key); got != value {
+			t.Errorf("AuthCodeURL() %s = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	provider := newFakeProvider(t)
+	provider.issuer = "https://attacker.example.com"
+
+	client := provider.client(time.Now())
+
+	_, err := client.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+	if !errors.Is(err, ErrDiscoveryFailed) {
+		t.Errorf("AuthCodeURL() error = %v, want %v", err, ErrDiscoveryFailed)
+	}
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// Example from RFC 7636 appendix B.
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	want := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	if got := CodeChallenge(verifier); got != want {
+		t.Errorf("CodeChallenge() = %q, want %q", got, want)
+	}
+}
+
+func TestRandomString(t *testing.T) {
+	a, err := RandomString(32)
+	if err != nil {
+		t.Fatalf("RandomString() error = %v", err)
+	}
+
+	b, err := RandomString(32)
+	if err != nil {
+		t.Fatalf("RandomString() error = %v", err)
+	}
+
+	if len(a) != 43 {
+		t.Errorf("RandomString(32) length = %d, want 43", len(a))
+	}
+
+	if a == b {
+		t.Error("RandomString() returned the same value twice")
+	}
+}
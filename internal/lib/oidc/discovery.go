@@ -0,0 +1,58 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+func (c *Client) discover(ctx context.Context) (*Discovery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.discovery != nil {
+		return c.discovery, nil
+	}
+
+	endpoint := strings.TrimSuffix(c.config.Issuer, "/") + "/.well-known/openid-configuration"
+
+	discovery := &Discovery{}
+	if err := c.getJSON(ctx, endpoint, discovery); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDiscoveryFailed, err)
+	}
+
+	// The issuer must be exactly the one configured, otherwise tokens from
+	// another tenant of the same provider could be accepted.
+	if discovery.Issuer != c.config.Issuer {
+		return nil, fmt.Errorf("%w: issuer %q does not match %q", ErrDiscoveryFailed, discovery.Issuer, c.config.Issuer)
+	}
+
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: missing endpoints", ErrDiscoveryFailed)
+	}
+
+	c.discovery = discovery
+	return discovery, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", res.StatusCode, endpoint)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
@@ -0,0 +1,11 @@
+package oidc
+
+import "errors"
+
+var (
+	ErrDiscoveryFailed = errors.New("the provider metadata could not be loaded")
+	ErrExchangeFailed  = errors.New("the authorization code could not be exchanged")
+	ErrInvalidIDToken  = errors.New("the ID token is invalid")
+	ErrInvalidNonce    = errors.New("the ID token nonce does not match")
+	ErrUnknownKey      = errors.New("the ID token is signed with an unknown key")
+)
@@ -0,0 +1,63 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	TokenType        string `json:"token_type"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (c *Client) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*Claims, error) {
+	discovery, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", c.config.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", c.config.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// RFC 6749 section 2.3.1 requires the credentials to be form-encoded
+	// before they are put in the basic auth header.
+	req.SetBasicAuth(url.QueryEscape(c.config.ClientID), url.QueryEscape(c.config.ClientSecret))
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExchangeFailed, err)
+	}
+	defer res.Body.Close()
+
+	token := &tokenResponse{}
+	if err := json.NewDecoder(res.Body).Decode(token); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExchangeFailed, err)
+	}
+
+	if res.StatusCode != http.StatusOK || token.Error != "" {
+		return nil, fmt.Errorf("%w: %s %s", ErrExchangeFailed, token.Error, token.ErrorDescription)
+	}
+
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: the response has no ID token", ErrExchangeFailed)
+	}
+
+	return c.VerifyIDToken(ctx, token.IDToken, nonce)
+}
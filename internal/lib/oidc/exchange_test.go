@@ -0,0 +1,69 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExchange(t *testing.T) {
+	now := time.Now()
+	provider := newFakeProvider(t)
+	client := provider.client(now)
+
+	verifier, err := RandomString(32)
+	if err != nil {
+		t.Fatalf("RandomString() error = %v", err)
+	}
+
+	code := provider.authorize(CodeChallenge(verifier), provider.claims(now, "nonce-1"))
+
+	claims, err := client.Exchange(context.Background(), code, verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+
+	want := Claims{
+		Subject:       "user-789",
+		Email:         "john.doe@example.com",
+		EmailVerified: true,
+		Name:          "John Doe",
+		GivenName:     "John",
+		FamilyName:    "Doe",
+	}
+
+	if *claims != want {
+		t.Errorf("Exchange() claims = %+v, want %+v", *claims, want)
+	}
+}
+
+func TestExchangeWrongCodeVerifier(t *testing.T) {
+	now := time.Now()
+	provider := newFakeProvider(t)
+	client := provider.client(now)
+
+	code := provider.authorize(CodeChallenge("the-real-verifier"), provider.claims(now, "nonce-1"))
+
+	_, err := client.Exchange(context.Background(), code, "a-guessed-verifier", "nonce-1")
+	if !errors.Is(err, ErrExchangeFailed) {
+		t.Errorf("Exchange() error = %v, want %v", err, ErrExchangeFailed)
+	}
+}
+
+func TestExchangeCodeUsedTwice(t *testing.T) {
+	now := time.Now()
+	provider := newFakeProvider(t)
+	client := provider.client(now)
+
+	code := provider.authorize(CodeChallenge("verifier"), provider.claims(now, "nonce-1"))
+
+	if _, err := client.Exchange(context.Background(), code, "verifier", "nonce-1"); err != nil {
+		t.Fatalf("first Exchange() error = %v", err)
+	}
+
+	_, err := client.Exchange(context.Background(), code, "verifier", "nonce-1")
+	if !errors.Is(err, ErrExchangeFailed) {
+		t.Errorf("second Exchange() error = %v, want %v", err, ErrExchangeFailed)
+	}
+}
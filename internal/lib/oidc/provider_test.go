@@ -0,0 +1,206 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "client-123"
+	testClientSecret = "secret-456"
+	testRedirectURL  = "http://localhost:3000/oidc/callback"
+)
+
+// fakeProvider is a minimal OpenID Connect provider serving discovery, the
+// key set and the token endpoint, used to test the client offline.
+type fakeProvider struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu             sync.Mutex
+	key            *rsa.PrivateKey
+	kid            string
+	issuer         string
+	authorizations map[string]fakeAuthorization
+	jwksRequests   int
+}
+
+type fakeAuthorization struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	t.Helper()
+
+	f := &fakeProvider{
+		t:              t,
+		authorizations: make(map[string]fakeAuthorization),
+	}
+	f.rotateKey("key-1")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", f.handleDiscovery)
+	mux.HandleFunc("GET /jwks", f.handleJWKS)
+	mux.HandleFunc("POST /token", f.handleToken)
+
+	f.server = httptest.NewServer(mux)
+	f.issuer = f.server.URL
+	t.Cleanup(f.server.Close)
+
+	return f
+}
+
+func (f *fakeProvider) client(now time.Time) *Client {
+	client := New(Config{
+		Name:         "fake",
+		Issuer:       f.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+	})
+	client.HTTPClient = f.server.Client()
+	client.Now = func() time.Time { return now }
+	return client
+}
+
+func (f *fakeProvider) rotateKey(kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		f.t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.key = key
+	f.kid = kid
+}
+
+// claims returns valid ID token claims for the given time and nonce.
+func (f *fakeProvider) claims(now time.Time, nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            f.server.URL,
+		"sub":            "user-789",
+		"aud":            testClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"nonce":          nonce,
+		"email":          "john.doe@example.com",
+		"email_verified": true,
+		"name":           "John Doe",
+		"given_name":     "John",
+		"family_name":    "Doe",
+	}
+}
+
+func (f *fakeProvider) sign(claims jwt.MapClaims) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = f.kid
+
+	signed, err := token.SignedString(f.key)
+	if err != nil {
+		f.t.Fatalf("SignedString() error = %v", err)
+	}
+
+	return signed
+}
+
+// authorize records a successful user consent and returns the code the
+// provider would redirect back with.
+func (f *fakeProvider) authorize(codeChallenge string, claims jwt.MapClaims) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	code := "code-" + big.NewInt(int64(len(f.authorizations)+1)).String()
+	f.authorizations[code] = fakeAuthorization{challenge: codeChallenge, claims: claims}
+	return code
+}
+
+func (f *fakeProvider) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	issuer := f.issuer
+	f.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                 issuer,
+		"authorization_endpoint": f.server.URL + "/authorize",
+		"token_endpoint":         f.server.URL + "/token",
+		"jwks_uri":               f.server.URL + "/jwks",
+	})
+}
+
+func (f *fakeProvider) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.jwksRequests++
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": f.kid,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(f.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(f.key.E)).Bytes()),
+			},
+		},
+	})
+}
+
+func (f *fakeProvider) handleToken(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok || clientID != testClientID || clientSecret != testClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	f.mu.Lock()
+	authorization, ok := f.authorizations[r.PostForm.Get("code")]
+	delete(f.authorizations, r.PostForm.Get("code"))
+	f.mu.Unlock()
+
+	switch {
+	case r.PostForm.Get("grant_type") != "authorization_code",
+		r.PostForm.Get("redirect_uri") != testRedirectURL,
+		!ok,
+		CodeChallenge(r.PostForm.Get("code_verifier")) != authorization.challenge:
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"error":             "invalid_grant",
+			"error_description": "the code or the code verifier is invalid",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"id_token":     f.sign(authorization.claims),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
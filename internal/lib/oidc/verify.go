@@ -0,0 +1,94 @@
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signingMethods are the asymmetric algorithms accepted for ID tokens. HMAC
+// is left out on purpose, as it would make the client secret a signing key.
+var signingMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce           string       `json:"nonce"`
+	AuthorizedParty string       `json:"azp"`
+	Email           string       `json:"email"`
+	EmailVerified   flexibleBool `json:"email_verified"`
+	Name            string       `json:"name"`
+	GivenName       string       `json:"given_name"`
+	FamilyName      string       `json:"family_name"`
+}
+
+// flexibleBool accepts both true and "true", as some providers send
+// email_verified as a string.
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case bool:
+		*b = flexibleBool(v)
+	case string:
+		*b = flexibleBool(v == "true")
+	}
+
+	return nil
+}
+
+// VerifyIDToken checks the signature of the ID token against the provider
+// keys, its issuer, audience, lifetime and nonce.
+func (c *Client) VerifyIDToken(ctx context.Context, rawIDToken string, nonce string) (*Claims, error) {
+	discovery, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &idTokenClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return c.key(ctx, discovery.JWKSURI, kid)
+	},
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(c.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(c.Leeway),
+		jwt.WithTimeFunc(c.Now),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+
+	// A token issued to several audiences must name this client as the
+	// party it was issued for.
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != c.config.ClientID {
+		return nil, fmt.Errorf("%w: authorized party %q does not match", ErrInvalidIDToken, claims.AuthorizedParty)
+	}
+
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, ErrInvalidNonce
+	}
+
+	return &Claims{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+	}, nil
+}
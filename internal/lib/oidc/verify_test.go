@@ -0,0 +1,203 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestVerifyIDToken(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	provider := newFakeProvider(t)
+
+	tests := []struct {
+		name    string
+		claims  func(claims jwt.MapClaims)
+		nonce   string
+		sign    func(claims jwt.MapClaims) string
+		wantErr error
+	}{
+		{
+			name:  "Valid token",
+			nonce: "nonce-1",
+		},
+		{
+			name: "Email verified sent as a string",
+			claims: func(claims jwt.MapClaims) {
+				claims["email_verified"] = "true"
+			},
+			nonce: "nonce-1",
+		},
+		{
+			name:    "Nonce mismatch",
+			nonce:   "another-nonce",
+			wantErr: ErrInvalidNonce,
+		},
+		{
+			name: "Missing nonce",
+			claims: func(claims jwt.MapClaims) {
+				delete(claims, "nonce")
+			},
+			nonce:   "nonce-1",
+			wantErr: ErrInvalidNonce,
+		},
+		{
+			name: "Wrong audience",
+			claims: func(claims jwt.MapClaims) {
+				claims["aud"] = "another-client"
+			},
+			nonce:   "nonce-1",
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name: "Several audiences without authorized party",
+			claims: func(claims jwt.MapClaims) {
+				claims["aud"] = []string{testClientID, "another-client"}
+			},
+			nonce:   "nonce-1",
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name: "Several audiences with authorized party",
+			claims: func(claims jwt.MapClaims) {
+				claims["aud"] = []string{testClientID, "another-client"}
+				claims["azp"] = testClientID
+			},
+			nonce: "nonce-1",
+		},
+		{
+			name: "Wrong issuer",
+			claims: func(claims jwt.MapClaims) {
+				claims["iss"] = "https://attacker.example.com"
+			},
+			nonce:   "nonce-1",
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name: "Expired",
+			claims: func(claims jwt.MapClaims) {
+				claims["exp"] = now.Add(-2 * time.Minute).Unix()
+			},
+			nonce:   "nonce-1",
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name: "Expired within leeway",
+			claims: func(claims jwt.MapClaims) {
+				claims["exp"] = now.Add(-30 * time.Second).Unix()
+			},
+			nonce: "nonce-1",
+		},
+		{
+			name: "Missing expiry",
+			claims: func(claims jwt.MapClaims) {
+				delete(claims, "exp")
+			},
+			nonce:   "nonce-1",
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name: "Missing subject",
+			claims: func(claims jwt.MapClaims) {
+				delete(claims, "sub")
+			},
+			nonce:   "nonce-1",
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name:  "Signed with HMAC using the client secret",
+			nonce: "nonce-1",
+			sign: func(claims jwt.MapClaims) string {
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+				token.Header["kid"] = "key-1"
+				signed, _ := token.SignedString([]byte(testClientSecret))
+				return signed
+			},
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name:  "Unsigned",
+			nonce: "nonce-1",
+			sign: func(claims jwt.MapClaims) string {
+				token := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
+				signed, _ := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+				return signed
+			},
+			wantErr: ErrInvalidIDToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := provider.client(now)
+
+			claims := provider.claims(now, "nonce-1")
+			if tt.claims != nil {
+				tt.claims(claims)
+			}
+
+			sign := provider.sign
+			if tt.sign != nil {
+				sign = tt.sign
+			}
+
+			_, err := client.VerifyIDToken(context.Background(), sign(claims), tt.nonce)
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("VerifyIDToken() error = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyIDToken() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenForgedSignature(t *testing.T) {
+	now := time.Now()
+	provider := newFakeProvider(t)
+	attacker := newFakeProvider(t)
+
+	client := provider.client(now)
+
+	// Same key ID and claims, signed with a key the provider never published.
+	token := attacker.sign(provider.claims(now, "nonce-1"))
+
+	_, err := client.VerifyIDToken(context.Background(), token, "nonce-1")
+	if !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("VerifyIDToken() error = %v, want %v", err, ErrInvalidIDToken)
+	}
+}
+
+func TestVerifyIDTokenKeyRotation(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	provider := newFakeProvider(t)
+
+	client := provider.client(now)
+	client.Now = func() time.Time { return now }
+
+	if _, err := client.VerifyIDToken(context.Background(), provider.sign(provider.claims(now, "n")), "n"); err != nil {
+		t.Fatalf("VerifyIDToken() before rotation error = %v", err)
+	}
+
+	provider.rotateKey("key-2")
+	rotated := provider.sign(provider.claims(now, "n"))
+
+	// The key set was fetched moments ago, so the new key is not looked up yet.
+	if _, err := client.VerifyIDToken(context.Background(), rotated, "n"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("VerifyIDToken() right after rotation error = %v, want %v", err, ErrInvalidIDToken)
+	}
+
+	now = now.Add(jwksRefreshInterval)
+	rotated = provider.sign(provider.claims(now, "n"))
+
+	if _, err := client.VerifyIDToken(context.Background(), rotated, "n"); err != nil {
+		t.Fatalf("VerifyIDToken() after refresh interval error = %v", err)
+	}
+
+	if provider.jwksRequests != 2 {
+		t.Errorf("key set requests = %d, want 2", provider.jwksRequests)
+	}
+}
@@ -0,0 +1,32 @@
+package ratelimit
+
+import (
+	"gintama/internal/lib"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc returns the key a request is counted under.
+type KeyFunc func(c *gin.Context) string
+
+// ByIP counts requests per client address.
+func ByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUID counts requests per authenticated user, falling back to the client
+// address when the request is anonymous. It must run after Authorization.
+func ByUID(c *gin.Context) string {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return ByIP(c)
+	}
+
+	return "uid:" + uid.String()
+}
+
+// ByRoute counts every request of the route group together, regardless of
+// the client.
+func ByRoute(c *gin.Context) string {
+	return "route"
+}
@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Allow counts a request against key and reports whether it fits the policy.
+func (l *Limiter) Allow(key string, policy Policy) (Result, error) {
+	now := l.Now()
+
+	count, resetAt, err := l.Store.Increment(key, policy.Window, now)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Allowed:    count <= policy.Limit,
+		Limit:      policy.Limit,
+		Remaining:  max(policy.Limit-count, 0),
+		ResetAfter: max(resetAt.Sub(now), 0),
+	}, nil
+}
+
+// Handler limits the requests of a route group. The name keeps the counters
+// of different groups apart, so each group has its own budget per key.
+func (l *Limiter) Handler(name string, policy Policy, key KeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := l.Allow(name+":"+key(c), policy)
+		if err != nil {
+			// A store outage must not take the API down with it.
+			_ = c.Error(err)
+			c.Next()
+			return
+		}
+
+		reset := strconv.Itoa(seconds(result.ResetAfter))
+
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", reset)
+
+		if !result.Allowed {
+			c.Header("Retry-After", reset)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"message": "Too many requests",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// seconds rounds up so clients never retry before the window has ended.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,157 @@
+package ratelimit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gintama/internal/lib"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type failingStore struct{}
+
+func (failingStore) Increment(string, time.Duration, time.Time) (int, time.Time, error) {
+	return 0, time.Time{}, errors.New("store unavailable")
+}
+
+func newTestLimiter(now *time.Time) *Limiter {
+	limiter := New(NewMemoryStore(100))
+	limiter.Now = func() time.Time { return *now }
+	return limiter
+}
+
+func TestAllow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+	policy := Policy{Limit: 2, Window: time.Minute}
+
+	tests := []struct {
+		name          string
+		advance       time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantReset     time.Duration
+	}{
+		{name: "First request", wantAllowed: true, wantRemaining: 1, wantReset: time.Minute},
+		{name: "Last allowed request", advance: 10 * time.Second, wantAllowed: true, wantRemaining: 0, wantReset: 50 * time.Second},
+		{name: "Over the limit", advance: 10 * time.Second, wantAllowed: false, wantRemaining: 0, wantReset: 40 * time.Second},
+		{name: "Next window", advance: 40 * time.Second, wantAllowed: true, wantRemaining: 1, wantReset: time.Minute},
+	}
+
+	for _, tt := range tests {
+		now = now.Add(tt.advance)
+
+		result, err := limiter.Allow("ip:1", policy)
+		if err != nil {
+			t.Fatalf("%s: Allow() error = %v", tt.name, err)
+		}
+		if result.Allowed != tt.wantAllowed {
+			t.Errorf("%s: Allow() allowed = %v, want %v", tt.name, result.Allowed, tt.wantAllowed)
+		}
+		if result.Remaining != tt.wantRemaining {
+			t.Errorf("%s: Allow() remaining = %d, want %d", tt.name, result.Remaining, tt.wantRemaining)
+		}
+		if result.ResetAfter != tt.wantReset {
+			t.Errorf("%s: Allow() reset = %v, want %v", tt.name, result.ResetAfter, tt.wantReset)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+
+	router := gin.New()
+	router.GET("/test", limiter.Handler("test", Policy{Limit: 1, Window: time.Minute}, ByIP), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("203.0.113.1:1234")
+	if w.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get("RateLimit-Limit"); got != "1" {
+		t.Errorf("RateLimit-Limit = %q, want %q", got, "1")
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("RateLimit-Remaining = %q, want %q", got, "0")
+	}
+	if got := w.Header().Get("RateLimit-Reset"); got != "60" {
+		t.Errorf("RateLimit-Reset = %q, want %q", got, "60")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+
+	w = request("203.0.113.1:1234")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if got := w.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After = %q, want %q", got, "60")
+	}
+
+	w = request("203.0.113.2:1234")
+	if w.Code != http.StatusOK {
+		t.Errorf("request from another client status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestHandlerStoreFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := New(failingStore{})
+
+	router := gin.New()
+	router.GET("/test", limiter.Handler("test", Policy{Limit: 1, Window: time.Minute}, ByIP), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestKeyFuncs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uid := uuid.Must(uuid.NewV7())
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+	c.Request.RemoteAddr = "203.0.113.1:1234"
+
+	if got, want := ByIP(c), "ip:203.0.113.1"; got != want {
+		t.Errorf("ByIP() = %q, want %q", got, want)
+	}
+
+	if got, want := ByUID(c), "ip:203.0.113.1"; got != want {
+		t.Errorf("ByUID() without a user = %q, want %q", got, want)
+	}
+
+	lib.ContextSetUID(c, uid)
+	if got, want := ByUID(c), "uid:"+uid.String(); got != want {
+		t.Errorf("ByUID() = %q, want %q", got, want)
+	}
+
+	if got, want := ByRoute(c), "route"; got != want {
+		t.Errorf("ByRoute() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryWindow struct {
+	count   int
+	resetAt time.Time
+}
+
+// MemoryStore keeps counters in process memory. It suits a single instance;
+// deployments with several instances need a shared store.
+type MemoryStore struct {
+	mu        sync.Mutex
+	windows   map[string]*memoryWindow
+	maxKeys   int
+	lastSweep time.Time
+}
+
+// sweepInterval is how often ended windows are evicted.
+const sweepInterval = time.Minute
+
+// NewMemoryStore returns a store holding at most maxKeys counters. When it is
+// full, ended windows are evicted first, then the window closest to ending.
+func NewMemoryStore(maxKeys int) *MemoryStore {
+	return &MemoryStore{
+		windows: make(map[string]*memoryWindow),
+		maxKeys: maxKeys,
+	}
+}
+
+func (s *MemoryStore) Increment(key string, window time.Duration, now time.Time) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	w, ok := s.windows[key]
+	if !ok || !now.Before(w.resetAt) {
+		if !ok && len(s.windows) >= s.maxKeys {
+			s.evict(now)
+		}
+
+		w = &memoryWindow{resetAt: now.Add(window)}
+		s.windows[key] = w
+	}
+
+	w.count++
+
+	return w.count, w.resetAt, nil
+}
+
+// Len returns the number of counters held.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.windows)
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, w := range s.windows {
+		if !now.Before(w.resetAt) {
+			delete(s.windows, key)
+		}
+	}
+
+	s.lastSweep = now
+}
+
+func (s *MemoryStore) evict(now time.Time) {
+	s.sweep(now)
+	if len(s.windows) < s.maxKeys {
+		return
+	}
+
+	var oldestKey string
+	var oldest time.Time
+	for key, w := range s.windows {
+		if oldestKey == "" || w.resetAt.Before(oldest) {
+			oldestKey = key
+			oldest = w.resetAt
+		}
+	}
+
+	delete(s.windows, oldestKey)
+}
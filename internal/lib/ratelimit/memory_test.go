@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreIncrement(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore(10)
+
+	for want := 1; want <= 3; want++ {
+		count, resetAt, err := store.Increment("ip:1", time.Minute, now)
+		if err != nil {
+			t.Fatalf("Increment() error = %v", err)
+		}
+		if count != want {
+			t.Errorf("Increment() count = %d, want %d", count, want)
+		}
+		if !resetAt.Equal(now.Add(time.Minute)) {
+			t.Errorf("Increment() resetAt = %v, want %v", resetAt, now.Add(time.Minute))
+		}
+	}
+
+	count, _, _ := store.Increment("ip:2", time.Minute, now)
+	if count != 1 {
+		t.Errorf("Increment() of another key count = %d, want 1", count)
+	}
+
+	later := now.Add(time.Minute)
+	count, resetAt, _ := store.Increment("ip:1", time.Minute, later)
+	if count != 1 {
+		t.Errorf("Increment() after the window count = %d, want 1", count)
+	}
+	if !resetAt.Equal(later.Add(time.Minute)) {
+		t.Errorf("Increment() after the window resetAt = %v, want %v", resetAt, later.Add(time.Minute))
+	}
+}
+
+func TestMemoryStoreEviction(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Ended windows are swept", func(t *testing.T) {
+		store := NewMemoryStore(10)
+		store.Increment("a", time.Second, now)
+		store.Increment("b", time.Hour, now)
+
+		store.Increment("c", time.Hour, now.Add(2*time.Minute))
+
+		if got := store.Len(); got != 2 {
+			t.Errorf("Len() = %d, want 2", got)
+		}
+	})
+
+	t.Run("Full store evicts the window closest to ending", func(t *testing.T) {
+		store := NewMemoryStore(2)
+		store.Increment("a", time.Hour, now)
+		store.Increment("b", time.Minute, now)
+		store.Increment("c", time.Hour, now)
+
+		if got := store.Len(); got != 2 {
+			t.Fatalf("Len() = %d, want 2", got)
+		}
+
+		count, _, _ := store.Increment("a", time.Hour, now)
+		if count != 2 {
+			t.Errorf("Increment() of kept key count = %d, want 2", count)
+		}
+
+		count, _, _ = store.Increment("b", time.Minute, now)
+		if count != 1 {
+			t.Errorf("Increment() of evicted key count = %d, want 1", count)
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package ratelimit
+
+import "time"
+
+// Policy allows Limit requests per key within each fixed Window.
+type Policy struct {
+	Limit  int
+	Window time.Duration
+}
+
+// Result describes the state of a key after a request has been counted.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter is the time left until the current window ends.
+	ResetAfter time.Duration
+}
+
+// Store counts requests per key in fixed windows. Increment adds one request
+// to the window of key that is active at now, starting a new window when the
+// previous one ended, and returns the count and the end of the window.
+type Store interface {
+	Increment(key string, window time.Duration, now time.Time) (int, time.Time, error)
+}
+
+// Limiter applies policies to keys using a Store shared by every route.
+type Limiter struct {
+	Store Store
+	// Now returns the current time, replaced by a fake clock in tests.
+	Now func() time.Time
+}
+
+func New(store Store) *Limiter {
+	return &Limiter{
+		Store: store,
+		Now:   time.Now,
+	}
+}
@@ -0,0 +1,16 @@
+package revocation
+
+// JTIKey revokes a single token.
+func JTIKey(jti string) string {
+	return "jti:" + jti
+}
+
+// SessionKey revokes every token issued for a session.
+func SessionKey(sessionID string) string {
+	return "session:" + sessionID
+}
+
+// UserKey revokes every token issued to a user up to the revocation time.
+func UserKey(userID string) string {
+	return "user:" + userID
+}
@@ -0,0 +1,72 @@
+package revocation
+
+import (
+	"container/list"
+
+	"gintama/internal/models"
+)
+
+// lruItem caches the result of a lookup. A nil entry records that the key is
+// not revoked, so repeated misses do not reach the source.
+type lruItem struct {
+	key   string
+	entry *models.TokenRevocation
+}
+
+type lru struct {
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List // front is the most recently used
+}
+
+func newLRU(capacity int) *lru {
+	return &lru{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (l *lru) get(key string) (*models.TokenRevocation, bool) {
+	element, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	l.order.MoveToFront(element)
+	return element.Value.(*lruItem).entry, true
+}
+
+// put stores the entry and reports whether a revoked entry had to be evicted
+// to make room for it.
+func (l *lru) put(key string, entry *models.TokenRevocation) bool {
+	if element, ok := l.items[key]; ok {
+		element.Value.(*lruItem).entry = entry
+		l.order.MoveToFront(element)
+		return false
+	}
+
+	evictedRevoked := false
+	if l.order.Len() >= l.capacity {
+		oldest := l.order.Back()
+		item := oldest.Value.(*lruItem)
+		evictedRevoked = item.entry != nil
+
+		l.order.Remove(oldest)
+		delete(l.items, item.key)
+	}
+
+	l.items[key] = l.order.PushFront(&lruItem{key: key, entry: entry})
+	return evictedRevoked
+}
+
+func (l *lru) remove(key string) {
+	if element, ok := l.items[key]; ok {
+		l.order.Remove(element)
+		delete(l.items, key)
+	}
+}
+
+func (l *lru) len() int {
+	return l.order.Len()
+}
@@ -0,0 +1,201 @@
+package revocation
+
+import (
+	"context"
+	"time"
+
+	"gintama/internal/models"
+)
+
+// syncOverlap re-reads entries changed shortly before the last watermark, as
+// transactions that started earlier may commit after a sync has run.
+const syncOverlap = time.Minute
+
+// IsRevoked reports whether a token issued at issuedAt is revoked by any of
+// keys, typically its jti, session and user keys.
+func (s *Store) IsRevoked(issuedAt time.Time, keys ...string) (bool, error) {
+	s.mu.Lock()
+
+	var missing []string
+	for _, key := range keys {
+		entry, ok := s.cache.get(key)
+		if !ok {
+			missing = append(missing, key)
+			continue
+		}
+
+		if s.revokes(entry, issuedAt) {
+			s.mu.Unlock()
+			return true, nil
+		}
+	}
+
+	complete := s.complete
+	s.mu.Unlock()
+
+	if len(missing) == 0 || complete {
+		return false, nil
+	}
+
+	entries, err := s.Source.Get(missing...)
+	if err != nil {
+		return false, err
+	}
+
+	found := make(map[string]*models.TokenRevocation, len(entries))
+	for _, entry := range entries {
+		found[entry.Key] = entry
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revoked := false
+	for _, key := range missing {
+		// A revocation applied while the source was queried wins over the
+		// older lookup result.
+		entry, ok := s.cache.get(key)
+		if !ok {
+			entry = found[key]
+			s.put(key, entry)
+		}
+
+		if s.revokes(entry, issuedAt) {
+			revoked = true
+		}
+	}
+
+	return revoked, nil
+}
+
+// Revoke stores entries in the source and applies them locally right away,
+// other instances pick them up on their next sync.
+func (s *Store) Revoke(entries ...*models.TokenRevocation) error {
+	if err := s.Source.Insert(entries...); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range entries {
+		s.merge(entry)
+	}
+
+	return nil
+}
+
+// Sync applies the entries changed in the source since the previous sync.
+// The first sync loads every entry, after which keys missing from the cache
+// are known not to be revoked as long as the cache never overflowed.
+func (s *Store) Sync() error {
+	s.mu.Lock()
+	since := s.watermark
+	first := since.IsZero()
+	s.mu.Unlock()
+
+	if !first {
+		since = since.Add(-syncOverlap)
+	}
+
+	entries, watermark, err := s.Source.Changed(since)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if first {
+		s.complete = true
+	}
+
+	now := s.Now()
+	for _, entry := range entries {
+		if entry.ExpiresAt.Before(now) {
+			s.cache.remove(entry.Key)
+			continue
+		}
+
+		s.merge(entry)
+	}
+
+	if watermark.After(s.watermark) {
+		s.watermark = watermark
+	}
+
+	s.evictExpired(now)
+
+	return nil
+}
+
+// Run syncs every interval until ctx is done, reporting failures to onError.
+func (s *Store) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Sync(); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Len returns the number of cached lookups, revoked or not.
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cache.len()
+}
+
+func (s *Store) revokes(entry *models.TokenRevocation, issuedAt time.Time) bool {
+	if entry == nil || entry.ExpiresAt.Before(s.Now()) {
+		return false
+	}
+
+	return !issuedAt.After(entry.RevokedBefore)
+}
+
+// merge caches entry, keeping the latest revocation when the key is known.
+func (s *Store) merge(entry *models.TokenRevocation) {
+	current, ok := s.cache.get(entry.Key)
+	if ok && current != nil {
+		merged := *current
+		if entry.RevokedBefore.After(merged.RevokedBefore) {
+			merged.RevokedBefore = entry.RevokedBefore
+		}
+		if entry.ExpiresAt.After(merged.ExpiresAt) {
+			merged.ExpiresAt = entry.ExpiresAt
+		}
+		entry = &merged
+	}
+
+	s.put(entry.Key, entry)
+}
+
+func (s *Store) put(key string, entry *models.TokenRevocation) {
+	if s.cache.put(key, entry) {
+		// A revoked key was forgotten, misses must be checked in the source.
+		s.complete = false
+	}
+}
+
+func (s *Store) evictExpired(now time.Time) {
+	var expired []string
+	for key, element := range s.cache.items {
+		entry := element.Value.(*lruItem).entry
+		if entry != nil && entry.ExpiresAt.Before(now) {
+			expired = append(expired, key)
+		}
+	}
+
+	for _, key := range expired {
+		s.cache.remove(key)
+	}
+}
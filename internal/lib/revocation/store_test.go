@@ -0,0 +1,281 @@
+package revocation
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gintama/internal/models"
+)
+
+// fakeSource is an in-memory Source that counts lookups.
+type fakeSource struct {
+	mu      sync.Mutex
+	entries map[string]*models.TokenRevocation
+	updated map[string]time.Time
+	clock   time.Time
+	gets    int
+	err     error
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{
+		entries: make(map[string]*models.TokenRevocation),
+		updated: make(map[string]time.Time),
+		clock:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (f *fakeSource) Insert(entries ...*models.TokenRevocation) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return f.err
+	}
+
+	for _, entry := range entries {
+		f.clock = f.clock.Add(time.Second)
+		copied := *entry
+		f.entries[entry.Key] = &copied
+		f.updated[entry.Key] = f.clock
+	}
+
+	return nil
+}
+
+func (f *fakeSource) Get(keys ...string) ([]*models.TokenRevocation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.gets++
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	var found []*models.TokenRevocation
+	for _, key := range keys {
+		if entry, ok := f.entries[key]; ok {
+			copied := *entry
+			found = append(found, &copied)
+		}
+	}
+
+	return found, nil
+}
+
+func (f *fakeSource) Changed(since time.Time) ([]*models.TokenRevocation, time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return nil, time.Time{}, f.err
+	}
+
+	var changed []*models.TokenRevocation
+	watermark := since
+	for key, entry := range f.entries {
+		if f.updated[key].After(since) {
+			copied := *entry
+			changed = append(changed, &copied)
+			if f.updated[key].After(watermark) {
+				watermark = f.updated[key]
+			}
+		}
+	}
+
+	return changed, watermark, nil
+}
+
+func TestIsRevoked(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	source := newFakeSource()
+	_ = source.Insert(
+		&models.TokenRevocation{Key: JTIKey("revoked-jti"), RevokedBefore: now, ExpiresAt: now.Add(time.Hour)},
+		&models.TokenRevocation{Key: UserKey("user-1"), RevokedBefore: now, ExpiresAt: now.Add(time.Hour)},
+		&models.TokenRevocation{Key: SessionKey("expired-session"), RevokedBefore: now, ExpiresAt: now.Add(-time.Minute)},
+	)
+
+	store := New(source, 100)
+	store.Now = func() time.Time { return now }
+
+	if err := store.Sync(); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		issuedAt time.Time
+		keys     []string
+		want     bool
+	}{
+		{"Revoked jti", now.Add(-time.Minute), []string{JTIKey("revoked-jti")}, true},
+		{"Unknown jti", now.Add(-time.Minute), []string{JTIKey("other-jti")}, false},
+		{"Token issued before the user was revoked", now.Add(-time.Minute), []string{JTIKey("a"), UserKey("user-1")}, true},
+		{"Token issued in the same second as the revocation", now, []string{UserKey("user-1")}, true},
+		{"Token issued after the user was revoked", now.Add(time.Second), []string{UserKey("user-1")}, false},
+		{"Revocation past its expiry", now.Add(-time.Hour), []string{SessionKey("expired-session")}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := store.IsRevoked(tc.issuedAt, tc.keys...)
+			if err != nil {
+				t.Fatalf("IsRevoked() error = %v", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("IsRevoked() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	if source.gets != 0 {
+		t.Errorf("source lookups = %d, want 0 while the cache is complete", source.gets)
+	}
+}
+
+func TestIsRevokedBeforeFirstSync(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	source := newFakeSource()
+	_ = source.Insert(&models.TokenRevocation{Key: JTIKey("revoked"), RevokedBefore: now, ExpiresAt: now.Add(time.Hour)})
+
+	store := New(source, 100)
+	store.Now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		revoked, err := store.IsRevoked(now.Add(-time.Minute), JTIKey("revoked"), JTIKey("unknown"))
+		if err != nil {
+			t.Fatalf("IsRevoked() error = %v", err)
+		}
+
+		if !revoked {
+			t.Error("IsRevoked() = false, want true from the source")
+		}
+	}
+
+	if source.gets != 1 {
+		t.Errorf("source lookups = %d, want 1 as results are cached", source.gets)
+	}
+
+	source.err = errors.New("connection refused")
+
+	if _, err := store.IsRevoked(now, JTIKey("another")); err == nil {
+		t.Error("IsRevoked() error = nil, want the source error")
+	}
+}
+
+func TestIsRevokedAfterOverflow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	source := newFakeSource()
+	_ = source.Insert(
+		&models.TokenRevocation{Key: JTIKey("a"), RevokedBefore: now, ExpiresAt: now.Add(time.Hour)},
+		&models.TokenRevocation{Key: JTIKey("b"), RevokedBefore: now, ExpiresAt: now.Add(time.Hour)},
+		&models.TokenRevocation{Key: JTIKey("c"), RevokedBefore: now, ExpiresAt: now.Add(time.Hour)},
+	)
+
+	store := New(source, 2)
+	store.Now = func() time.Time { return now }
+
+	if err := store.Sync(); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	if store.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", store.Len())
+	}
+
+	// One entry did not fit, so every key is still reported as revoked by
+	// falling back to the source.
+	for _, key := range []string{"a", "b", "c"} {
+		revoked, err := store.IsRevoked(now.Add(-time.Minute), JTIKey(key))
+		if err != nil {
+			t.Fatalf("IsRevoked() error = %v", err)
+		}
+
+		if !revoked {
+			t.Errorf("IsRevoked(%s) = false, want true", key)
+		}
+	}
+
+	if source.gets == 0 {
+		t.Error("source lookups = 0, want lookups after the cache overflowed")
+	}
+}
+
+func TestSyncPicksUpRevocations(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	source := newFakeSource()
+	store := New(source, 100)
+	store.Now = func() time.Time { return now }
+
+	if err := store.Sync(); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	if revoked, _ := store.IsRevoked(now, SessionKey("s1")); revoked {
+		t.Fatal("IsRevoked() = true before the session was revoked")
+	}
+
+	// Another instance revokes the session.
+	_ = source.Insert(&models.TokenRevocation{Key: SessionKey("s1"), RevokedBefore: now, ExpiresAt: now.Add(time.Hour)})
+
+	if err := store.Sync(); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	if revoked, _ := store.IsRevoked(now, SessionKey("s1")); !revoked {
+		t.Error("IsRevoked() = false after sync, want true")
+	}
+
+	// Expired entries are dropped on sync.
+	now = now.Add(2 * time.Hour)
+	if err := store.Sync(); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	if store.Len() != 0 {
+		t.Errorf("Len() = %d, want 0 once every entry expired", store.Len())
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	source := newFakeSource()
+	store := New(source, 100)
+	store.Now = func() time.Time { return now }
+
+	if err := store.Sync(); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	err := store.Revoke(&models.TokenRevocation{Key: UserKey("u1"), RevokedBefore: now, ExpiresAt: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+
+	if revoked, _ := store.IsRevoked(now.Add(-time.Minute), UserKey("u1")); !revoked {
+		t.Error("IsRevoked() = false right after Revoke(), want true")
+	}
+
+	if _, ok := source.entries[UserKey("u1")]; !ok {
+		t.Error("Revoke() did not store the entry in the source")
+	}
+
+	source.err = errors.New("connection refused")
+
+	err = store.Revoke(&models.TokenRevocation{Key: UserKey("u2"), RevokedBefore: now, ExpiresAt: now.Add(time.Hour)})
+	if err == nil {
+		t.Fatal("Revoke() error = nil, want the source error")
+	}
+
+	if revoked, _ := store.IsRevoked(now.Add(-time.Minute), UserKey("u2")); revoked {
+		t.Error("IsRevoked() = true for a revocation that failed to store")
+	}
+}
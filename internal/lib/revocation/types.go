@@ -0,0 +1,42 @@
+package revocation
+
+import (
+	"sync"
+	"time"
+
+	"gintama/internal/models"
+)
+
+// Source is the shared source of truth the store syncs from.
+type Source interface {
+	// Insert stores entries, keeping the latest RevokedBefore and ExpiresAt
+	// when a key is revoked again.
+	Insert(entries ...*models.TokenRevocation) error
+	// Get returns the entries stored for keys, skipping unknown keys.
+	Get(keys ...string) ([]*models.TokenRevocation, error)
+	// Changed returns the entries inserted or updated after since together
+	// with the watermark to pass on the next call.
+	Changed(since time.Time) ([]*models.TokenRevocation, time.Time, error)
+}
+
+// Store answers revocation checks from memory. Entries are kept in a least
+// recently used cache filled by periodic syncs; when the cache cannot hold
+// every entry, keys it does not know are looked up in the source.
+type Store struct {
+	Source Source
+	// Now returns the current time, replaced by a fake clock in tests.
+	Now func() time.Time
+
+	mu        sync.Mutex
+	cache     *lru
+	complete  bool // the cache holds every unexpired entry of the source
+	watermark time.Time
+}
+
+func New(source Source, capacity int) *Store {
+	return &Store{
+		Source: source,
+		Now:    time.Now,
+		cache:  newLRU(capacity),
+	}
+}
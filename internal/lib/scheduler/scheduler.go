@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+// Add registers a job. Jobs added after Start are not run.
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every job right away and then on each of its intervals.
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.loop(ctx, job)
+		}()
+	}
+}
+
+// Stop cancels the running jobs and waits for them to return.
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.run(ctx, job)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	if s.Locker != nil {
+		unlock, ok, err := s.Locker.TryLock("scheduler:" + job.Name)
+		if err != nil {
+			s.report(job.Name, err)
+			return
+		}
+
+		if !ok {
+			return
+		}
+
+		defer func() {
+			if err := unlock(); err != nil {
+				s.report(job.Name, err)
+			}
+		}()
+	}
+
+	if err := job.Run(ctx); err != nil {
+		s.report(job.Name, err)
+	}
+}
+
+func (s *Scheduler) report(job string, err error) {
+	if s.OnError != nil {
+		s.OnError(job, err)
+	}
+}
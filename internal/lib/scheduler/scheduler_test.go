@@ -0,0 +1,213 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeLocker is an in-memory Locker shared by the schedulers of a test.
+type fakeLocker struct {
+	mu     sync.Mutex
+	held   map[string]bool
+	err    error
+	unlock error
+}
+
+func newFakeLocker() *fakeLocker {
+	return &fakeLocker{held: make(map[string]bool)}
+}
+
+func (f *fakeLocker) TryLock(name string) (func() error, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return nil, false, f.err
+	}
+
+	if f.held[name] {
+		return nil, false, nil
+	}
+
+	f.held[name] = true
+
+	return func() error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		delete(f.held, name)
+		return f.unlock
+	}, true, nil
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerRunsJobs(t *testing.T) {
+	var runs atomic.Int32
+
+	s := New(nil, nil)
+	s.Add(Job{
+		Name:     "count",
+		Interval: 5 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	})
+
+	s.Start(context.Background())
+	waitFor(t, func() bool { return runs.Load() >= 3 })
+	s.Stop()
+
+	stopped := runs.Load()
+	time.Sleep(20 * time.Millisecond)
+	if got := runs.Load(); got != stopped {
+		t.Errorf("runs after Stop() = %d, want %d", got, stopped)
+	}
+}
+
+func TestSchedulerLock(t *testing.T) {
+	t.Run("Only one instance runs the job", func(t *testing.T) {
+		locker := newFakeLocker()
+		release := make(chan struct{})
+		var running, runs atomic.Int32
+
+		job := Job{
+			Name:     "exclusive",
+			Interval: time.Millisecond,
+			Run: func(ctx context.Context) error {
+				if running.Add(1) > 1 {
+					t.Error("job ran on two instances at once")
+				}
+				defer running.Add(-1)
+
+				runs.Add(1)
+				<-release
+				return nil
+			},
+		}
+
+		first, second := New(locker, nil), New(locker, nil)
+		first.Add(job)
+		second.Add(job)
+
+		first.Start(context.Background())
+		second.Start(context.Background())
+
+		waitFor(t, func() bool { return runs.Load() == 1 })
+		time.Sleep(10 * time.Millisecond)
+		if got := runs.Load(); got != 1 {
+			t.Errorf("runs while locked = %d, want 1", got)
+		}
+
+		close(release)
+		first.Stop()
+		second.Stop()
+	})
+
+	t.Run("Lock errors are reported", func(t *testing.T) {
+		locker := newFakeLocker()
+		locker.err = errors.New("connection refused")
+
+		var reported atomic.Value
+		var runs atomic.Int32
+
+		s := New(locker, func(job string, err error) {
+			reported.Store(job + ": " + err.Error())
+		})
+		s.Add(Job{
+			Name:     "locked",
+			Interval: time.Hour,
+			Run: func(ctx context.Context) error {
+				runs.Add(1)
+				return nil
+			},
+		})
+
+		s.Start(context.Background())
+		waitFor(t, func() bool { return reported.Load() != nil })
+		s.Stop()
+
+		if got := reported.Load(); got != "locked: connection refused" {
+			t.Errorf("reported = %v, want %q", got, "locked: connection refused")
+		}
+		if got := runs.Load(); got != 0 {
+			t.Errorf("runs = %d, want 0", got)
+		}
+	})
+}
+
+func TestSchedulerReportsErrors(t *testing.T) {
+	var mu sync.Mutex
+	var reported []string
+
+	s := New(nil, func(job string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, job+": "+err.Error())
+	})
+	s.Add(Job{
+		Name:     "failing",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			return errors.New("boom")
+		},
+	})
+
+	s.Start(context.Background())
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(reported) > 0
+	})
+	s.Stop()
+
+	if reported[0] != "failing: boom" {
+		t.Errorf("reported = %q, want %q", reported[0], "failing: boom")
+	}
+}
+
+func TestSchedulerStopCancelsRunningJob(t *testing.T) {
+	started := make(chan struct{})
+
+	s := New(nil, nil)
+	s.Add(Job{
+		Name:     "slow",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return nil
+		},
+	})
+
+	s.Start(context.Background())
+	<-started
+
+	done := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stop() did not return")
+	}
+}
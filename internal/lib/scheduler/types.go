@@ -0,0 +1,41 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Locker makes sure a job runs on a single instance at a time.
+type Locker interface {
+	// TryLock takes the lock named name without waiting. ok is false when
+	// another instance holds it, otherwise unlock releases it.
+	TryLock(name string) (unlock func() error, ok bool, err error)
+}
+
+// Job is work repeated every Interval. Run should return soon after ctx is
+// done so shutdown does not hang.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler runs jobs in the background of the process until stopped.
+type Scheduler struct {
+	// Locker is optional, without it every instance runs every job.
+	Locker Locker
+	// OnError reports failed runs, the job is retried on its next tick.
+	OnError func(job string, err error)
+
+	jobs   []Job
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(locker Locker, onError func(job string, err error)) *Scheduler {
+	return &Scheduler{
+		Locker:  locker,
+		OnError: onError,
+	}
+}
@@ -0,0 +1,320 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Local stores objects as files below a root directory. Its signed URLs point
+// at baseURL followed by the key, and are checked with VerifySignedURL by the
+// route serving them.
+type Local struct {
+	root    string
+	baseURL string
+	secret  []byte
+	now     func() time.Time
+}
+
+func NewLocal(root string, baseURL string, secret []byte) (*Local, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("local storage needs a secret to sign urls")
+	}
+
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, err
+	}
+
+	return &Local{
+		root:    root,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		secret:  secret,
+		now:     time.Now,
+	}, nil
+}
+
+func (s *Local) path(key string) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+
+	if key == multipartDir || strings.HasPrefix(key, multipartDir+"/") {
+		return "", ErrInvalidKey
+	}
+
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temporary file first, so readers never see a partial object.
+func (s *Local) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(path, func(w io.Writer) error {
+		return copySize(w, body, size)
+	})
+}
+
+func (s *Local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func (s *Local) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// SignedURL returns baseURL/key with the expiry and an HMAC of both in the
+// query string.
+func (s *Local) SignedURL(key string, expiresIn time.Duration) (string, time.Time, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := s.now().Add(expiresIn).Truncate(time.Second)
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", s.signature(key, expires))
+
+	return s.baseURL + "/" + uriEncode(key, false) + "?" + query.Encode(), expiresAt, nil
+}
+
+// VerifySignedURL checks the expires and signature parameters of a URL made
+// by SignedURL for key.
+func (s *Local) VerifySignedURL(key string, query url.Values) error {
+	expires := query.Get("expires")
+	signature, err := hex.DecodeString(query.Get("signature"))
+	if err != nil || expires == "" {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal(signature, s.signatureBytes(key, expires)) {
+		return ErrInvalidSignature
+	}
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if !s.now().Before(time.Unix(expiresAt, 0)) {
+		return ErrURLExpired
+	}
+
+	return nil
+}
+
+func (s *Local) signature(key string, expires string) string {
+	return hex.EncodeToString(s.signatureBytes(key, expires))
+}
+
+func (s *Local) signatureBytes(key string, expires string) []byte {
+	return hmacSHA256(s.secret, key+"\n"+expires)
+}
+
+// multipartDir holds the parts of multipart uploads below the root, out of
+// reach of keys.
+const multipartDir = ".multipart"
+
+// CreateMultipart makes a directory the parts are kept in until the upload is
+// completed or aborted.
+func (s *Local) CreateMultipart(ctx context.Context, key string, contentType string) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+
+	uploadID := make([]byte, 16)
+	if _, err := rand.Read(uploadID); err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(s.root, multipartDir, hex.EncodeToString(uploadID))
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(uploadID), nil
+}
+
+// PutPart returns the MD5 of the part as its ETag, as S3 does.
+func (s *Local) PutPart(ctx context.Context, key string, uploadID string, number int, body io.Reader, size int64) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+
+	dir, err := s.partsDir(uploadID)
+	if err != nil {
+		return "", err
+	}
+
+	if number < 1 {
+		return "", fmt.Errorf("part number must be at least 1, got %d", number)
+	}
+
+	hash := md5.New()
+	err = writeFile(filepath.Join(dir, strconv.Itoa(number)), func(w io.Writer) error {
+		return copySize(io.MultiWriter(w, hash), body, size)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (s *Local) CompleteMultipart(ctx context.Context, key string, uploadID string, parts []Part) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	dir, err := s.partsDir(uploadID)
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(path, func(w io.Writer) error {
+		for _, part := range parts {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			if err := appendFile(w, filepath.Join(dir, strconv.Itoa(part.Number))); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(dir)
+}
+
+func (s *Local) AbortMultipart(ctx context.Context, key string, uploadID string) error {
+	dir, err := s.partsDir(uploadID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return os.RemoveAll(dir)
+}
+
+// partsDir returns the directory of an upload made by CreateMultipart, or
+// ErrNotFound once it was completed or aborted.
+func (s *Local) partsDir(uploadID string) (string, error) {
+	if id, err := hex.DecodeString(uploadID); err != nil || len(id) != 16 {
+		return "", ErrInvalidUploadID
+	}
+
+	dir := filepath.Join(s.root, multipartDir, uploadID)
+	if _, err := os.Stat(dir); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// writeFile creates path with the content write produces. It writes to a
+// temporary file first, so readers never see a partial file.
+func writeFile(path string, write func(w io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+// copySize copies body to w and fails unless it holds exactly size bytes.
+func copySize(w io.Writer, body io.Reader, size int64) error {
+	written, err := io.Copy(w, io.LimitReader(body, size+1))
+	if err != nil {
+		return err
+	}
+
+	if written != size {
+		return fmt.Errorf("object size is %d bytes, want %d", written, size)
+	}
+
+	return nil
+}
+
+func appendFile(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrNotFound
+		}
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(w, file)
+	return err
+}
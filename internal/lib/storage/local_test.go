@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalPutGetDelete(t *testing.T) {
+	root := t.TempDir()
+
+	local, err := NewLocal(root, "http://localhost:8080/v1/files", []byte("secret"))
+	if err != nil {
+		t.Fatalf("NewLocal() error = %v", err)
+	}
+
+	ctx := context.Background()
+
+	if err := local.Put(ctx, "uploads/user/file.txt", strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "uploads", "user", "file.txt")); err != nil {
+		t.Errorf("Put() did not write the file: %v", err)
+	}
+
+	body, err := local.Get(ctx, "uploads/user/file.txt")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	content, _ := io.ReadAll(body)
+	body.Close()
+
+	if string(content) != "hello" {
+		t.Errorf("Get() content = %q, want hello", content)
+	}
+
+	if err := local.Delete(ctx, "uploads/user/file.txt"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if _, err := local.Get(ctx, "uploads/user/file.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() error = %v, want %v", err, ErrNotFound)
+	}
+
+	if err := local.Delete(ctx, "uploads/user/file.txt"); err != nil {
+		t.Errorf("Delete() of a missing object error = %v, want nil", err)
+	}
+}
+
+func TestLocalPutSizeMismatch(t *testing.T) {
+	local, _ := NewLocal(t.TempDir(), "http://localhost:8080/v1/files", []byte("secret"))
+
+	for _, body := range []string{"hell", "hello!"} {
+		err := local.Put(context.Background(), "file.txt", strings.NewReader(body), 5, "text/plain")
+		if err == nil {
+			t.Errorf("Put(%q) error = nil, want a size error", body)
+		}
+	}
+
+	if _, err := local.Get(context.Background(), "file.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	valid := []string{"file.txt", "uploads/user/file.txt"}
+	invalid := []string{"", "/file.txt", "../file.txt", "uploads/../../file.txt", "uploads//file.txt", "uploads\\file.txt", "uploads/."}
+
+	for _, key := range valid {
+		if err := ValidateKey(key); err != nil {
+			t.Errorf("ValidateKey(%q) error = %v, want nil", key, err)
+		}
+	}
+
+	for _, key := range invalid {
+		if err := ValidateKey(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("ValidateKey(%q) error = %v, want %v", key, err, ErrInvalidKey)
+		}
+	}
+}
+
+func TestLocalSignedURL(t *testing.T) {
+	local, _ := NewLocal(t.TempDir(), "http://localhost:8080/v1/files/", []byte("secret"))
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	local.now = func() time.Time { return now }
+
+	signedURL, expiresAt, err := local.SignedURL("uploads/user/a b", time.Hour)
+	if err != nil {
+		t.Fatalf("SignedURL() error = %v", err)
+	}
+
+	if !expiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("SignedURL() expiresAt = %v, want %v", expiresAt, now.Add(time.Hour))
+	}
+
+	u, err := url.Parse(signedURL)
+	if err != nil {
+		t.Fatalf("SignedURL() = %s is not a URL: %v", signedURL, err)
+	}
+
+	if u.Path != "/v1/files/uploads/user/a b" {
+		t.Errorf("SignedURL() path = %s, want /v1/files/uploads/user/a b", u.Path)
+	}
+
+	if err := local.VerifySignedURL("uploads/user/a b", u.Query()); err != nil {
+		t.Errorf("VerifySignedURL() error = %v, want nil", err)
+	}
+
+	if err := local.VerifySignedURL("uploads/user/other", u.Query()); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifySignedURL() of another key error = %v, want %v", err, ErrInvalidSignature)
+	}
+
+	tampered := u.Query()
+	tampered.Set("expires", "9999999999")
+	if err := local.VerifySignedURL("uploads/user/a b", tampered); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifySignedURL() with a changed expiry error = %v, want %v", err, ErrInvalidSignature)
+	}
+
+	now = now.Add(time.Hour)
+	if err := local.VerifySignedURL("uploads/user/a b", u.Query()); !errors.Is(err, ErrURLExpired) {
+		t.Errorf("VerifySignedURL() after expiry error = %v, want %v", err, ErrURLExpired)
+	}
+}
+
+func TestLocalMultipart(t *testing.T) {
+	root := t.TempDir()
+	local, _ := NewLocal(root, "http://localhost:8080/v1/files", []byte("secret"))
+	ctx := context.Background()
+
+	uploadID, err := local.CreateMultipart(ctx, "uploads/big.bin", "application/octet-stream")
+	if err != nil {
+		t.Fatalf("CreateMultipart() error = %v", err)
+	}
+
+	// Parts can arrive in any order, they are joined by number.
+	chunks := map[int]string{2: "world", 1: "hello "}
+	parts := make([]Part, 2)
+	for number, chunk := range chunks {
+		etag, err := local.PutPart(ctx, "uploads/big.bin", uploadID, number, strings.NewReader(chunk), int64(len(chunk)))
+		if err != nil {
+			t.Fatalf("PutPart(%d) error = %v", number, err)
+		}
+		parts[number-1] = Part{Number: number, ETag: etag}
+	}
+
+	if want := "f814893777bcc2295fff05f00e508da6"; parts[0].ETag != want {
+		t.Errorf("PutPart() etag = %q, want the MD5 %q", parts[0].ETag, want)
+	}
+
+	if err := local.CompleteMultipart(ctx, "uploads/big.bin", uploadID, parts); err != nil {
+		t.Fatalf("CompleteMultipart() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, "uploads", "big.bin"))
+	if err != nil || string(content) != "hello world" {
+		t.Errorf("completed object = %q (%v), want hello world", content, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, multipartDir, uploadID)); !os.IsNotExist(err) {
+		t.Errorf("parts directory left after CompleteMultipart(): %v", err)
+	}
+
+	if _, err := local.PutPart(ctx, "uploads/big.bin", uploadID, 3, strings.NewReader("!"), 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("PutPart() after CompleteMultipart() error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestLocalMultipartErrors(t *testing.T) {
+	local, _ := NewLocal(t.TempDir(), "http://localhost:8080/v1/files", []byte("secret"))
+	ctx := context.Background()
+
+	for _, uploadID := range []string{"", "../../etc", strings.Repeat("z", 32)} {
+		_, err := local.PutPart(ctx, "file.bin", uploadID, 1, strings.NewReader("a"), 1)
+		if !errors.Is(err, ErrInvalidUploadID) {
+			t.Errorf("PutPart(%q) error = %v, want %v", uploadID, err, ErrInvalidUploadID)
+		}
+	}
+
+	if _, err := local.CreateMultipart(ctx, multipartDir+"/file.bin", "text/plain"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("CreateMultipart() into the parts directory error = %v, want %v", err, ErrInvalidKey)
+	}
+
+	uploadID, _ := local.CreateMultipart(ctx, "file.bin", "text/plain")
+
+	err := local.CompleteMultipart(ctx, "file.bin", uploadID, []Part{{Number: 1}})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("CompleteMultipart() with a missing part error = %v, want %v", err, ErrNotFound)
+	}
+
+	if err := local.AbortMultipart(ctx, "file.bin", uploadID); err != nil {
+		t.Errorf("AbortMultipart() error = %v", err)
+	}
+
+	if err := local.AbortMultipart(ctx, "file.bin", uploadID); err != nil {
+		t.Errorf("AbortMultipart() of an unknown upload error = %v, want nil", err)
+	}
+}
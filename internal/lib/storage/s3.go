@@ -0,0 +1,337 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type S3Config struct {
+	// Endpoint is the base URL of the service, e.g. https://s3.us-east-1.amazonaws.com
+	// or http://localhost:9000 for MinIO.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle addresses the bucket in the path instead of the host name,
+	// which most self-hosted servers need.
+	PathStyle bool
+}
+
+// S3 stores objects in a bucket of an S3 compatible service.
+type S3 struct {
+	config   S3Config
+	endpoint *url.URL
+	signer   sigV4Signer
+	client   *http.Client
+	now      func() time.Time
+}
+
+func NewS3(config S3Config) (*S3, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, errors.New("s3 endpoint must be an absolute URL")
+	}
+
+	if config.Bucket == "" || config.Region == "" {
+		return nil, errors.New("s3 bucket and region must be provided")
+	}
+
+	return &S3{
+		config:   config,
+		endpoint: endpoint,
+		signer: sigV4Signer{
+			accessKey: config.AccessKey,
+			secretKey: config.SecretKey,
+			region:    config.Region,
+			service:   "s3",
+		},
+		client: &http.Client{Timeout: 5 * time.Minute},
+		now:    time.Now,
+	}, nil
+}
+
+func (s *S3) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	basePath := strings.TrimSuffix(u.Path, "/")
+
+	if s.config.PathStyle {
+		u.Path = basePath + "/" + s.config.Bucket + "/" + key
+	} else {
+		u.Host = s.config.Bucket + "." + u.Host
+		u.Path = basePath + "/" + key
+	}
+	u.RawPath = uriEncode(u.Path, false)
+
+	return &u
+}
+
+// Put streams body without hashing it first, which S3 allows for signed
+// requests as UNSIGNED-PAYLOAD. Use an https endpoint outside development.
+func (s *S3) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), io.LimitReader(body, size))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.do(req, unsignedPayload)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// maxPresignExpiry is the longest validity S3 accepts for a presigned URL.
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// SignedURL presigns a GET of the object, valid for at most seven days.
+func (s *S3) SignedURL(key string, expiresIn time.Duration) (string, time.Time, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", time.Time{}, err
+	}
+
+	if expiresIn <= 0 || expiresIn > maxPresignExpiry {
+		return "", time.Time{}, fmt.Errorf("presigned url expiry must be between 1s and %s", maxPresignExpiry)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, s.objectURL(key).String(), nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := s.now().Truncate(time.Second)
+	s.signer.presign(req, expiresIn, now)
+
+	return req.URL.String(), now.Add(expiresIn), nil
+}
+
+func (s *S3) CreateMultipart(ctx context.Context, key string, contentType string) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+
+	u := s.objectURL(key)
+	u.RawQuery = "uploads="
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := s.doXML(req, emptyPayloadHash, &result); err != nil {
+		return "", err
+	}
+
+	if result.UploadID == "" {
+		return "", errors.New("s3 returned no multipart upload id")
+	}
+
+	return result.UploadID, nil
+}
+
+func (s *S3) PutPart(ctx context.Context, key string, uploadID string, number int, body io.Reader, size int64) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+
+	if uploadID == "" {
+		return "", ErrInvalidUploadID
+	}
+
+	u := s.objectURL(key)
+	u.RawQuery = url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), io.LimitReader(body, size))
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = size
+
+	resp, err := s.do(req, unsignedPayload)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	return resp.Header.Get("ETag"), nil
+}
+
+func (s *S3) CompleteMultipart(ctx context.Context, key string, uploadID string, parts []Part) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+
+	if uploadID == "" {
+		return ErrInvalidUploadID
+	}
+
+	type completedPart struct {
+		PartNumber int
+		ETag       string
+	}
+	payload := struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{}
+	for _, part := range parts {
+		payload.Parts = append(payload.Parts, completedPart{PartNumber: part.Number, ETag: part.ETag})
+	}
+
+	body, err := xml.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	u := s.objectURL(key)
+	u.RawQuery = url.Values{"uploadId": {uploadID}}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/xml")
+
+	// A failure can still come with 200, as an Error document.
+	var result struct {
+		XMLName xml.Name
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := s.doXML(req, hashHex(body), &result); err != nil {
+		return err
+	}
+
+	if result.XMLName.Local == "Error" {
+		return fmt.Errorf("s3 complete multipart upload: %s: %s", result.Code, result.Message)
+	}
+
+	return nil
+}
+
+func (s *S3) AbortMultipart(ctx context.Context, key string, uploadID string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+
+	if uploadID == "" {
+		return ErrInvalidUploadID
+	}
+
+	u := s.objectURL(key)
+	u.RawQuery = url.Values{"uploadId": {uploadID}}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// doXML sends req with do and decodes the XML response into v.
+func (s *S3) doXML(req *http.Request, payloadHash string, v any) error {
+	resp, err := s.do(req, payloadHash)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return xml.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// do signs and sends req. Responses other than 2xx are closed and returned as
+// errors, 404 as ErrNotFound.
+func (s *S3) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.signer.sign(req, payloadHash, s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, fmt.Errorf("s3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,301 @@
+package storage
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a minimal path style S3 server keeping objects in memory.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+	// parts of multipart uploads by upload id and part number.
+	parts map[string]map[int][]byte
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	fake := &fakeS3{objects: map[string][]byte{}, types: map[string]string{}, parts: map[string]map[int][]byte{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	return fake, server
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=access/") ||
+		!strings.Contains(auth, "/us-east-1/s3/aws4_request") ||
+		r.Header.Get("X-Amz-Date") == "" ||
+		r.Header.Get("X-Amz-Content-Sha256") == "" {
+		http.Error(w, "<Error><Code>AccessDenied</Code></Error>", http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	query := r.URL.Query()
+	if query.Has("uploads") || query.Has("uploadId") {
+		f.serveMultipart(w, r, query)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = body
+		f.types[r.URL.Path] = r.Header.Get("Content-Type")
+
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (f *fakeS3) serveMultipart(w http.ResponseWriter, r *http.Request, query url.Values) {
+	uploadID := query.Get("uploadId")
+
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		uploadID = fmt.Sprintf("upload-%d", len(f.parts)+1)
+		f.parts[uploadID] = map[int][]byte{}
+		f.types[r.URL.Path] = r.Header.Get("Content-Type")
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", uploadID)
+
+	case f.parts[uploadID] == nil:
+		http.Error(w, "<Error><Code>NoSuchUpload</Code></Error>", http.StatusNotFound)
+
+	case r.Method == http.MethodPut:
+		number, _ := strconv.Atoi(query.Get("partNumber"))
+		body, _ := io.ReadAll(r.Body)
+		f.parts[uploadID][number] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, number))
+
+	case r.Method == http.MethodPost:
+		var payload struct {
+			Parts []struct {
+				PartNumber int
+				ETag       string
+			} `xml:"Part"`
+		}
+		xml.NewDecoder(r.Body).Decode(&payload)
+
+		var object []byte
+		for _, part := range payload.Parts {
+			if part.ETag != fmt.Sprintf(`"etag-%d"`, part.PartNumber) {
+				fmt.Fprint(w, "<Error><Code>InvalidPart</Code><Message>bad etag</Message></Error>")
+				return
+			}
+			object = append(object, f.parts[uploadID][part.PartNumber]...)
+		}
+		f.objects[r.URL.Path] = object
+		delete(f.parts, uploadID)
+		fmt.Fprint(w, "<CompleteMultipartUploadResult></CompleteMultipartUploadResult>")
+
+	case r.Method == http.MethodDelete:
+		delete(f.parts, uploadID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func newTestS3(t *testing.T, endpoint string) *S3 {
+	s3, err := NewS3(S3Config{
+		Endpoint:  endpoint,
+		Region:    "us-east-1",
+		Bucket:    "bucket",
+		AccessKey: "access",
+		SecretKey: "secret",
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatalf("NewS3() error = %v", err)
+	}
+
+	return s3
+}
+
+func TestS3PutGetDelete(t *testing.T) {
+	fake, server := newFakeS3(t)
+	s3 := newTestS3(t, server.URL)
+	ctx := context.Background()
+
+	err := s3.Put(ctx, "uploads/a b.txt", strings.NewReader("hello"), 5, "text/plain")
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	if got := string(fake.objects["/bucket/uploads/a b.txt"]); got != "hello" {
+		t.Errorf("stored object = %q, want hello", got)
+	}
+
+	if got := fake.types["/bucket/uploads/a b.txt"]; got != "text/plain" {
+		t.Errorf("stored content type = %q, want text/plain", got)
+	}
+
+	body, err := s3.Get(ctx, "uploads/a b.txt")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	content, _ := io.ReadAll(body)
+	body.Close()
+
+	if string(content) != "hello" {
+		t.Errorf("Get() content = %q, want hello", content)
+	}
+
+	if err := s3.Delete(ctx, "uploads/a b.txt"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if _, err := s3.Get(ctx, "uploads/a b.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() error = %v, want %v", err, ErrNotFound)
+	}
+
+	if err := s3.Delete(ctx, "uploads/a b.txt"); err != nil {
+		t.Errorf("Delete() of a missing object error = %v, want nil", err)
+	}
+}
+
+func TestS3Errors(t *testing.T) {
+	_, server := newFakeS3(t)
+
+	s3 := newTestS3(t, server.URL)
+	s3.signer.accessKey = "wrong"
+
+	err := s3.Put(context.Background(), "key", strings.NewReader("hello"), 5, "text/plain")
+	if err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("Put() error = %v, want the AccessDenied response", err)
+	}
+
+	if err := s3.Put(context.Background(), "../key", strings.NewReader(""), 0, "text/plain"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Put() error = %v, want %v", err, ErrInvalidKey)
+	}
+}
+
+func TestS3ObjectURL(t *testing.T) {
+	config := S3Config{Endpoint: "https://s3.example.com", Region: "us-east-1", Bucket: "bucket"}
+
+	virtualHosted, _ := NewS3(config)
+	if got := virtualHosted.objectURL("a/b c").String(); got != "https://bucket.s3.example.com/a/b%20c" {
+		t.Errorf("objectURL() = %s", got)
+	}
+
+	config.PathStyle = true
+	pathStyle, _ := NewS3(config)
+	if got := pathStyle.objectURL("a/b c").String(); got != "https://s3.example.com/bucket/a/b%20c" {
+		t.Errorf("objectURL() = %s", got)
+	}
+}
+
+func TestS3SignedURL(t *testing.T) {
+	s3 := newTestS3(t, "http://localhost:9000")
+
+	signedURL, expiresAt, err := s3.SignedURL("uploads/user/file", time.Hour)
+	if err != nil {
+		t.Fatalf("SignedURL() error = %v", err)
+	}
+
+	if !strings.HasPrefix(signedURL, "http://localhost:9000/bucket/uploads/user/file?X-Amz-Algorithm=AWS4-HMAC-SHA256&") {
+		t.Errorf("SignedURL() = %s", signedURL)
+	}
+
+	if !strings.Contains(signedURL, "X-Amz-Expires=3600") || !strings.Contains(signedURL, "X-Amz-Signature=") {
+		t.Errorf("SignedURL() = %s, want expiry and signature parameters", signedURL)
+	}
+
+	if time.Until(expiresAt) > time.Hour || time.Until(expiresAt) < 59*time.Minute {
+		t.Errorf("SignedURL() expiresAt = %v, want an hour from now", expiresAt)
+	}
+
+	if _, _, err := s3.SignedURL("uploads/user/file", 8*24*time.Hour); err == nil {
+		t.Errorf("SignedURL() error = nil, want an error above seven days")
+	}
+}
+
+func TestS3Multipart(t *testing.T) {
+	fake, server := newFakeS3(t)
+	s3 := newTestS3(t, server.URL)
+	ctx := context.Background()
+
+	uploadID, err := s3.CreateMultipart(ctx, "uploads/big.bin", "application/octet-stream")
+	if err != nil {
+		t.Fatalf("CreateMultipart() error = %v", err)
+	}
+
+	var parts []Part
+	for i, chunk := range []string{"hello ", "world"} {
+		etag, err := s3.PutPart(ctx, "uploads/big.bin", uploadID, i+1, strings.NewReader(chunk), int64(len(chunk)))
+		if err != nil {
+			t.Fatalf("PutPart(%d) error = %v", i+1, err)
+		}
+		parts = append(parts, Part{Number: i + 1, ETag: etag})
+	}
+
+	if err := s3.CompleteMultipart(ctx, "uploads/big.bin", uploadID, parts); err != nil {
+		t.Fatalf("CompleteMultipart() error = %v", err)
+	}
+
+	if got := string(fake.objects["/bucket/uploads/big.bin"]); got != "hello world" {
+		t.Errorf("stored object = %q, want hello world", got)
+	}
+
+	if got := fake.types["/bucket/uploads/big.bin"]; got != "application/octet-stream" {
+		t.Errorf("stored content type = %q, want application/octet-stream", got)
+	}
+}
+
+func TestS3MultipartErrors(t *testing.T) {
+	fake, server := newFakeS3(t)
+	s3 := newTestS3(t, server.URL)
+	ctx := context.Background()
+
+	uploadID, err := s3.CreateMultipart(ctx, "uploads/big.bin", "application/octet-stream")
+	if err != nil {
+		t.Fatalf("CreateMultipart() error = %v", err)
+	}
+
+	if _, err := s3.PutPart(ctx, "uploads/big.bin", uploadID, 1, strings.NewReader("hello"), 5); err != nil {
+		t.Fatalf("PutPart() error = %v", err)
+	}
+
+	err = s3.CompleteMultipart(ctx, "uploads/big.bin", uploadID, []Part{{Number: 1, ETag: "wrong"}})
+	if err == nil || !strings.Contains(err.Error(), "InvalidPart") {
+		t.Errorf("CompleteMultipart() error = %v, want the InvalidPart error sent with 200", err)
+	}
+
+	if err := s3.AbortMultipart(ctx, "uploads/big.bin", uploadID); err != nil {
+		t.Fatalf("AbortMultipart() error = %v", err)
+	}
+
+	if len(fake.parts) != 0 {
+		t.Errorf("parts left after AbortMultipart() = %v", fake.parts)
+	}
+
+	if err := s3.AbortMultipart(ctx, "uploads/big.bin", uploadID); err != nil {
+		t.Errorf("AbortMultipart() of an unknown upload error = %v, want nil", err)
+	}
+
+	if _, err := s3.PutPart(ctx, "uploads/big.bin", uploadID, 2, strings.NewReader("hello"), 5); !errors.Is(err, ErrNotFound) {
+		t.Errorf("PutPart() after AbortMultipart() error = %v, want %v", err, ErrNotFound)
+	}
+}
@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AWS Signature Version 4, as accepted by S3 and compatible servers such as
+// MinIO. See https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-authenticating-requests.html
+const (
+	sigV4Algorithm   = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat  = "20060102T150405Z"
+	sigV4DateFormat  = "20060102"
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+type sigV4Signer struct {
+	accessKey string
+	secretKey string
+	region    string
+	service   string
+}
+
+// sign adds the x-amz-date, x-amz-content-sha256 and Authorization headers to
+// req. Every header already set on req is signed along with the host.
+func (s sigV4Signer) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+
+	req.Header.Set("X-Amz-Date", now.Format(sigV4TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers, signedHeaders := canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL),
+		canonicalQuery(req.URL.Query()),
+		headers,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := s.scope(now)
+	signature := s.signature(now, scope, canonicalRequest)
+
+	req.Header.Set("Authorization", sigV4Algorithm+
+		" Credential="+s.accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+signature)
+}
+
+// presign adds the query parameters that authorize req without headers until
+// expiresIn from now. Only the host is signed, so any client can follow it.
+func (s sigV4Signer) presign(req *http.Request, expiresIn time.Duration, now time.Time) {
+	now = now.UTC()
+	scope := s.scope(now)
+
+	query := req.URL.Query()
+	query.Set("X-Amz-Algorithm", sigV4Algorithm)
+	query.Set("X-Amz-Credential", s.accessKey+"/"+scope)
+	query.Set("X-Amz-Date", now.Format(sigV4TimeFormat))
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expiresIn/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL),
+		canonicalQuery(query),
+		"host:" + req.URL.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+
+	query.Set("X-Amz-Signature", s.signature(now, scope, canonicalRequest))
+	req.URL.RawQuery = canonicalQuery(query)
+}
+
+func (s sigV4Signer) scope(now time.Time) string {
+	return strings.Join([]string{now.Format(sigV4DateFormat), s.region, s.service, "aws4_request"}, "/")
+}
+
+func (s sigV4Signer) signature(now time.Time, scope string, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		now.Format(sigV4TimeFormat),
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format(sigV4DateFormat))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func canonicalHeaders(req *http.Request) (string, string) {
+	values := map[string]string{"host": req.URL.Host}
+	for name, value := range req.Header {
+		values[strings.ToLower(name)] = strings.TrimSpace(strings.Join(value, ","))
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var headers strings.Builder
+	for _, name := range names {
+		headers.WriteString(name + ":" + values[name] + "\n")
+	}
+
+	return headers.String(), strings.Join(names, ";")
+}
+
+func canonicalURI(u *url.URL) string {
+	if u.Path == "" {
+		return "/"
+	}
+
+	return uriEncode(u.Path, false)
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode escapes everything but unreserved characters, and slashes unless
+// encodeSlash is set, as SigV4 requires.
+func uriEncode(value string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+
+	var encoded strings.Builder
+	for i := 0; i < len(value); i++ {
+		b := value[i]
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '_', b == '.', b == '~':
+			encoded.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			encoded.WriteByte(b)
+		default:
+			encoded.WriteByte('%')
+			encoded.WriteByte(hexDigits[b>>4])
+			encoded.WriteByte(hexDigits[b&15])
+		}
+	}
+
+	return encoded.String()
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
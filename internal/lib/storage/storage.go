@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound         = errors.New("object not found")
+	ErrInvalidKey       = errors.New("invalid object key")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrURLExpired       = errors.New("url has expired")
+	ErrInvalidUploadID  = errors.New("invalid multipart upload id")
+)
+
+// Storage keeps uploaded files as objects addressed by slash separated keys.
+type Storage interface {
+	// Put stores size bytes of body under key, replacing any existing object.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get returns the content of the object, or ErrNotFound.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a link anyone holding it can download the object
+	// with until the returned expiry.
+	SignedURL(key string, expiresIn time.Duration) (string, time.Time, error)
+
+	// CreateMultipart starts an object written in parts and returns the id
+	// of the multipart upload.
+	CreateMultipart(ctx context.Context, key string, contentType string) (string, error)
+	// PutPart stores size bytes of body as part number, counted from 1, and
+	// returns its ETag. Every part but the last must hold at least
+	// MinPartSize bytes.
+	PutPart(ctx context.Context, key string, uploadID string, number int, body io.Reader, size int64) (string, error)
+	// CompleteMultipart joins parts in order into the object under key.
+	CompleteMultipart(ctx context.Context, key string, uploadID string, parts []Part) error
+	// AbortMultipart discards the stored parts. Aborting an unknown upload is
+	// not an error.
+	AbortMultipart(ctx context.Context, key string, uploadID string) error
+}
+
+// MinPartSize is the smallest part S3 accepts in a multipart upload, other
+// than the last.
+const MinPartSize = 5 << 20
+
+// Part is a stored part of a multipart upload.
+type Part struct {
+	Number int    `json:"number"`
+	ETag   string `json:"etag"`
+}
+
+// ValidateKey rejects keys that are empty, absolute or that could step out of
+// their prefix.
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidKey
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,8 @@
+package totp
+
+import "errors"
+
+var (
+	ErrInvalidSecret = errors.New("the secret is not a valid base32 string")
+	ErrInvalidCode   = errors.New("the code is invalid or expired")
+)
@@ -0,0 +1,87 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a random 160-bit secret encoded as base32, the
+// length recommended by RFC 4226 for HMAC-SHA1.
+func (t *TOTP) GenerateSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(b), nil
+}
+
+// URI returns the otpauth:// URI understood by authenticator apps, usually
+// rendered as a QR code by the client.
+func (t *TOTP) URI(issuer string, account string, secret string) string {
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(t.Digits))
+	query.Set("period", fmt.Sprint(int64(t.Period/time.Second)))
+
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// Generate returns the code for the period containing at.
+func (t *TOTP) Generate(secret string, at time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return t.hotp(key, t.step(at)), nil
+}
+
+func (t *TOTP) step(at time.Time) int64 {
+	return at.Unix() / int64(t.Period/time.Second)
+}
+
+// hotp implements the HOTP algorithm from RFC 4226 section 5.3.
+func (t *TOTP) hotp(key []byte, counter int64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for range t.Digits {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", t.Digits, value%mod)
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	normalized := strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	normalized = strings.TrimRight(normalized, "=")
+
+	key, err := encoding.DecodeString(normalized)
+	if err != nil || len(key) == 0 {
+		return nil, ErrInvalidSecret
+	}
+
+	return key, nil
+}
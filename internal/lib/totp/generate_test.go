@@ -0,0 +1,134 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed used by the test vectors in RFC 6238 appendix B.
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestGenerateRFC6238Vectors(t *testing.T) {
+	totp := New()
+	totp.Digits = 8
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.want, func(t *testing.T) {
+			got, err := totp.Generate(rfcSecret, time.Unix(tc.unix, 0))
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("Generate() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGenerateSixDigits(t *testing.T) {
+	totp := New()
+
+	// The six digit code is the last six digits of the eight digit vector.
+	got, err := totp.Generate(rfcSecret, time.Unix(59, 0))
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if got != "287082" {
+		t.Errorf("Generate() = %v, want %v", got, "287082")
+	}
+}
+
+func TestGenerateInvalidSecret(t *testing.T) {
+	totp := New()
+
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{"empty secret", ""},
+		{"not base32", "not-a-secret!"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := totp.Generate(tc.secret, time.Unix(59, 0))
+			if err != ErrInvalidSecret {
+				t.Errorf("Generate() error = %v, want %v", err, ErrInvalidSecret)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	totp := New()
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+
+	key, err := decodeSecret(secret)
+	if err != nil {
+		t.Fatalf("GenerateSecret() returned undecodable secret: %v", err)
+	}
+
+	if len(key) != 20 {
+		t.Errorf("GenerateSecret() key length = %d, want 20", len(key))
+	}
+
+	other, _ := totp.GenerateSecret()
+	if secret == other {
+		t.Error("GenerateSecret() returned the same secret twice")
+	}
+}
+
+func TestURI(t *testing.T) {
+	totp := New()
+
+	uri := totp.URI("gintama", "user@example.com", "JBSWY3DPEHPK3PXP")
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("URI() returned invalid url: %v", err)
+	}
+
+	if parsed.Scheme != "otpauth" || parsed.Host != "totp" {
+		t.Errorf("URI() = %v, want otpauth://totp/...", uri)
+	}
+
+	if !strings.HasPrefix(parsed.Path, "/gintama:user@example.com") {
+		t.Errorf("URI() label = %v, want issuer:account", parsed.Path)
+	}
+
+	query := parsed.Query()
+	want := map[string]string{
+		"secret":    "JBSWY3DPEHPK3PXP",
+		"issuer":    "gintama",
+		"algorithm": "SHA1",
+		"digits":    "6",
+		"period":    "30",
+	}
+
+	for key, value := range want {
+		if query.Get(key) != value {
+			t.Errorf("URI() %s = %v, want %v", key, query.Get(key), value)
+		}
+	}
+}
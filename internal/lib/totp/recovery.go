@@ -0,0 +1,51 @@
+package totp
+
+import (
+	"crypto/rand"
+	"strings"
+)
+
+// recoveryAlphabet leaves out characters that are easily confused when typed.
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// RecoveryCodes returns n single-use codes formatted as xxxxx-xxxxx.
+func RecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+
+	// Bytes above the largest multiple of the alphabet size are discarded so
+	// every character is equally likely.
+	limit := byte(256 - 256%len(recoveryAlphabet))
+	buf := make([]byte, 1)
+
+	for range n {
+		var sb strings.Builder
+		for i := 0; i < 10; {
+			if _, err := rand.Read(buf); err != nil {
+				return nil, err
+			}
+
+			if buf[0] >= limit {
+				continue
+			}
+
+			if i == 5 {
+				sb.WriteByte('-')
+			}
+			sb.WriteByte(recoveryAlphabet[int(buf[0])%len(recoveryAlphabet)])
+			i++
+		}
+
+		codes = append(codes, sb.String())
+	}
+
+	return codes, nil
+}
+
+// NormalizeRecoveryCode strips the formatting users may add or drop when
+// typing a recovery code, so it can be hashed and compared.
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	code = strings.ReplaceAll(code, " ", "")
+	return code
+}
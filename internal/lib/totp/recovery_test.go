@@ -0,0 +1,51 @@
+package totp
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := RecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("RecoveryCodes() error = %v", err)
+	}
+
+	if len(codes) != 10 {
+		t.Fatalf("RecoveryCodes() returned %d codes, want 10", len(codes))
+	}
+
+	format := regexp.MustCompile(`^[a-z2-9]{5}-[a-z2-9]{5}$`)
+	seen := make(map[string]bool)
+
+	for _, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("RecoveryCodes() code %q has unexpected format", code)
+		}
+
+		if seen[code] {
+			t.Errorf("RecoveryCodes() returned duplicate code %q", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"abcde-fghjk", "abcdefghjk"},
+		{"ABCDE-FGHJK", "abcdefghjk"},
+		{"abcde fghjk", "abcdefghjk"},
+		{"abcdefghjk", "abcdefghjk"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			if got := NormalizeRecoveryCode(tc.input); got != tc.want {
+				t.Errorf("NormalizeRecoveryCode() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package totp
+
+import "time"
+
+// TOTP generates and validates RFC 6238 time-based one-time passwords using
+// HMAC-SHA1, the only algorithm supported by every authenticator app.
+type TOTP struct {
+	Digits int
+	Period time.Duration
+	// Skew is the number of periods accepted before and after the current one
+	// to tolerate clock drift between the server and the device.
+	Skew int64
+	// Now returns the current time, replaced by a fake clock in tests.
+	Now func() time.Time
+}
+
+func New() *TOTP {
+	return &TOTP{
+		Digits: 6,
+		Period: 30 * time.Second,
+		Skew:   1,
+		Now:    time.Now,
+	}
+}
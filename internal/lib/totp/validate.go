@@ -0,0 +1,31 @@
+package totp
+
+import (
+	"crypto/subtle"
+	"strings"
+)
+
+// Validate checks code against the current period and the periods allowed by
+// Skew. It returns the matched time step, which callers persist to reject a
+// code that is replayed within its validity window.
+func (t *TOTP) Validate(secret string, code string) (int64, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, err
+	}
+
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) != t.Digits {
+		return 0, ErrInvalidCode
+	}
+
+	current := t.step(t.Now())
+	for offset := -t.Skew; offset <= t.Skew; offset++ {
+		step := current + offset
+		if subtle.ConstantTimeCompare([]byte(t.hotp(key, step)), []byte(code)) == 1 {
+			return step, nil
+		}
+	}
+
+	return 0, ErrInvalidCode
+}
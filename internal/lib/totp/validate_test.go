@@ -0,0 +1,105 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	totp := New()
+	totp.Now = func() time.Time { return now }
+
+	codeAt := func(at time.Time) string {
+		code, err := totp.Generate(rfcSecret, at)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		return code
+	}
+
+	currentStep := now.Unix() / 30
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantErr  error
+	}{
+		{
+			name:     "Current code",
+			code:     codeAt(now),
+			wantStep: currentStep,
+		},
+		{
+			name:     "Previous period within skew",
+			code:     codeAt(now.Add(-30 * time.Second)),
+			wantStep: currentStep - 1,
+		},
+		{
+			name:     "Next period within skew",
+			code:     codeAt(now.Add(30 * time.Second)),
+			wantStep: currentStep + 1,
+		},
+		{
+			name:    "Code outside skew",
+			code:    codeAt(now.Add(-90 * time.Second)),
+			wantErr: ErrInvalidCode,
+		},
+		{
+			name:    "Wrong length",
+			code:    "1234",
+			wantErr: ErrInvalidCode,
+		},
+		{
+			name:     "Code with spaces",
+			code:     codeAt(now)[:3] + " " + codeAt(now)[3:],
+			wantStep: currentStep,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			step, err := totp.Validate(rfcSecret, tc.code)
+			if err != tc.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+
+			if tc.wantErr == nil && step != tc.wantStep {
+				t.Errorf("Validate() step = %v, want %v", step, tc.wantStep)
+			}
+		})
+	}
+}
+
+func TestValidateFollowsClock(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	totp := New()
+	totp.Now = func() time.Time { return now }
+
+	code, err := totp.Generate(rfcSecret, now)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if _, err := totp.Validate(rfcSecret, code); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	// Two periods later the code has left the skew window.
+	now = now.Add(2 * totp.Period)
+
+	if _, err := totp.Validate(rfcSecret, code); err != ErrInvalidCode {
+		t.Errorf("Validate() error = %v, want %v", err, ErrInvalidCode)
+	}
+}
+
+func TestValidateInvalidSecret(t *testing.T) {
+	totp := New()
+
+	if _, err := totp.Validate("", "123456"); err != ErrInvalidSecret {
+		t.Errorf("Validate() error = %v, want %v", err, ErrInvalidSecret)
+	}
+}
@@ -1,61 +1,172 @@
 package middlewares
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"gintama/internal/lib"
+	"gintama/internal/lib/csrf"
 	"gintama/internal/lib/jwt"
+	"gintama/internal/lib/revocation"
+	"gintama/internal/repositories"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-func (m Middlewares) Authorization() gin.HandlerFunc {
+// Authorization accepts access tokens that verify and are not revoked. The
+// revocation list is kept in memory and session activity is recorded at most
+// once per session-touch-interval, so no query runs on most requests. Requests
+// sending an X-API-Key header are authorized by the key instead. opts change
+// where the token is read from for the routes it guards. Tokens read from the
+// cookie need a matching CSRF token on unsafe methods.
+func (m Middlewares) Authorization(opts ...jwt.ExtractOption) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		jwt := jwt.New(&m.app.Config.App)
+		if apiKey, ok := m.app.JWT.ExtractAPIKey(c); ok {
+			m.authorizeAPIKey(c, apiKey)
+			return
+		}
 
-		extractToken, err := jwt.ExtractToken(c)
+		extractToken, source, err := m.app.JWT.ExtractTokenSource(c, opts...)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"message": fmt.Sprintf("Unauthorized, %s", err.Error()),
 			})
 			return
 		}
 
-		session, err := m.app.Repositories.Session.GetByToken(extractToken)
+		// Browsers attach cookies to requests other sites start, so a cookie
+		// token only authorizes changes when the CSRF token comes with it.
+		if source == jwt.SourceCookie && !csrf.IsSafeMethod(c.Request.Method) {
+			if err := csrf.Verify(c); err != nil {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"message": fmt.Sprintf("Forbidden, %s", err.Error()),
+				})
+				return
+			}
+		}
+
+		claims, err := m.app.JWT.Verify(extractToken)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"message": fmt.Sprintf("Unauthorized, %s", err.Error()),
 			})
 			return
 		}
 
-		if session.ID != uuid.Nil {
-			claims, err := jwt.Verify(extractToken)
-			if err != nil {
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"message": fmt.Sprintf("Unauthorized, %s", err.Error()),
-				})
-				return
-			}
+		// Only access tokens carry a session, which keeps challenge and
+		// verification tokens from being used as bearer tokens.
+		userID, userErr := uuid.Parse(claims.Subject)
+		sessionID, sessionErr := uuid.Parse(claims.SessionID)
+		if claims.Purpose != "" || userErr != nil || sessionErr != nil || claims.IssuedAt == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"message": "Unauthorized, invalid session",
+			})
+			return
+		}
 
-			if claims.UID != session.UserID.String() {
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"message": "Unauthorized, invalid session",
-				})
-				return
-			}
+		revoked, err := m.app.Revocations.IsRevoked(
+			claims.IssuedAt.Time,
+			revocation.JTIKey(claims.ID),
+			revocation.SessionKey(claims.SessionID),
+			revocation.UserKey(claims.Subject),
+		)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"message": "Unable to check the token, please try again",
+			})
+			return
+		}
 
-			if claims.Exp < time.Now().Unix() {
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"message": "Unauthorized, expired session",
-				})
-				return
-			}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"message": "Unauthorized, token has been revoked",
+			})
+			return
+		}
+
+		if !m.touchSession(c, sessionID) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"message": "Unauthorized, session expired",
+			})
+			return
+		}
 
-			lib.ContextSetUID(c, uuid.MustParse(claims.UID))
+		lib.ContextSetUID(c, userID)
+		lib.ContextSetSessionID(c, sessionID)
+
+		c.Next()
+	}
+}
+
+// touchSession records the last seen time and IP of the session when due. It
+// reports false when the session went idle for longer than the idle timeout.
+func (m Middlewares) touchSession(c *gin.Context, sessionID uuid.UUID) bool {
+	now := time.Now()
+	key := "session:" + sessionID.String()
+
+	if !m.app.Activity.Allow(key, now) {
+		return true
+	}
+
+	err := m.app.Repositories.Session.Touch(sessionID, c.ClientIP(), m.app.Config.Auth.SessionIdleSince(now))
+	if err != nil {
+		m.app.Activity.Forget(key)
+
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return false
+		}
+
+		// Activity is best effort, a failed write must not sign anyone out.
+		m.app.Logger.Warn("failed to record session activity", "error", err)
+	}
+
+	return true
+}
+
+// authorizeAPIKey lets the request through as the owner of the key, limited to
+// the scopes of the key.
+func (m Middlewares) authorizeAPIKey(c *gin.Context, apiKey string) {
+	key, err := m.app.Repositories.APIKey.GetByKeyHash(lib.HashToken(apiKey))
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"message": "Unauthorized, invalid API key",
+			})
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+			})
+		}
+		return
+	}
+
+	activityKey := "api-key:" + key.ID.String()
+	if m.app.Activity.Allow(activityKey, time.Now()) {
+		if err := m.app.Repositories.APIKey.Touch(key.ID); err != nil {
+			m.app.Activity.Forget(activityKey)
+			m.app.Logger.Warn("failed to record api key activity", "error", err)
+		}
+	}
+
+	lib.ContextSetUID(c, key.UserID)
+	lib.ContextSetScopes(c, key.Scopes)
+
+	c.Next()
+}
+
+// RequireSession rejects requests authorized by an API key, for routes that
+// manage credentials or the session itself. It must run after Authorization.
+func (m Middlewares) RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, err := lib.ContextGetSessionID(c); err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"message": "Forbidden, this route requires a signed in user",
+			})
+			return
 		}
 
 		c.Next()
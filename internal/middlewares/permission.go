@@ -7,30 +7,39 @@ import (
 	"gintama/internal/lib"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
-func (m Middlewares) PermissionAccess(roles []string) gin.HandlerFunc {
+// RequirePermission only lets through users whose role grants the permission.
+// Requests made with an API key also need the permission among its scopes. It
+// must run after Authorization.
+func (m Middlewares) RequirePermission(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		uid, err := lib.ContextGetUID(c)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"message": err.Error(),
 			})
 			return
 		}
 
-		user, err := m.app.Repositories.User.GetByID(uid)
+		if scopes, ok := lib.ContextGetScopes(c); ok && !lib.Contains(scopes, permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"message": fmt.Sprintf("Forbidden, API key is missing scope %s", permission),
+			})
+			return
+		}
+
+		allowed, err := m.app.Repositories.RolePermission.UserHas(uid, permission)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"message": fmt.Sprintf("Unauthorized, permission access failed: %s", err.Error()),
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
 			})
 			return
 		}
 
-		if user.ID != uuid.Nil && !lib.Contains(roles, user.RoleID.String()) {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"message": "Unauthorized, permission access failed: you are not allowed!",
+		if !allowed {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"message": fmt.Sprintf("Forbidden, permission %s is required", permission),
 			})
 			return
 		}
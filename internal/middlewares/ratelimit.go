@@ -0,0 +1,13 @@
+package middlewares
+
+import (
+	"gintama/internal/lib/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit limits the requests of a route group per key. Routes sharing a
+// name share their budget.
+func (m Middlewares) RateLimit(name string, policy ratelimit.Policy, key ratelimit.KeyFunc) gin.HandlerFunc {
+	return m.app.RateLimiter.Handler(name, policy, key)
+}
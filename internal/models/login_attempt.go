@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+type LoginAttempt struct {
+	Key             string     `db:"key" json:"key"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+	Failures        int        `db:"failures" json:"failures"`
+	WindowStartedAt time.Time  `db:"window_started_at" json:"window_started_at"`
+	LastFailedAt    time.Time  `db:"last_failed_at" json:"last_failed_at"`
+	LockedUntil     *time.Time `db:"locked_until" json:"locked_until,omitempty"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OIDCState struct {
+	ID           string    `db:"id" json:"-"` // SHA-256 digest of the state parameter
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	Provider     string    `db:"provider" json:"provider"`
+	Nonce        string    `db:"nonce" json:"-"`
+	CodeVerifier string    `db:"code_verifier" json:"-"`
+	ExpiresAt    time.Time `db:"expires_at" json:"expires_at"`
+}
+
+type UserIdentity struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	UserID    uuid.UUID `db:"user_id" json:"user_id"`
+	Provider  string    `db:"provider" json:"provider"`
+	Subject   string    `db:"subject" json:"subject"`
+	Email     *string   `db:"email" json:"email,omitempty"`
+}
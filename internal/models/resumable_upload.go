@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"gintama/internal/lib/storage"
+
+	"github.com/google/uuid"
+)
+
+// ResumableUpload is a file received in chunks, which becomes an Upload once
+// Offset reaches Size.
+type ResumableUpload struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
+	UserID      uuid.UUID  `db:"user_id" json:"user_id"`
+	KeyFile     string     `db:"key_file" json:"-"`
+	FileName    string     `db:"file_name" json:"file_name"`
+	MimeType    string     `db:"mimetype" json:"mimetype,omitempty"`
+	Size        int64      `db:"size" json:"size"`
+	Offset      int64      `db:"upload_offset" json:"offset"`
+	MultipartID string     `db:"multipart_id" json:"-"`
+	PartETags   []string   `db:"part_etags" json:"-"`
+	Checksum    string     `db:"checksum" json:"checksum"` // SHA-256 of the whole file, hex encoded
+	HashState   []byte     `db:"hash_state" json:"-"`      // SHA-256 state over the received bytes
+	LockedUntil *time.Time `db:"locked_until" json:"-"`
+	ExpiresAt   time.Time  `db:"expires_at" json:"expires_at"`
+}
+
+// Parts returns the stored parts in order, as storage completes them.
+func (entity *ResumableUpload) Parts() []storage.Part {
+	parts := make([]storage.Part, len(entity.PartETags))
+	for i, etag := range entity.PartETags {
+		parts[i] = storage.Part{Number: i + 1, ETag: etag}
+	}
+
+	return parts
+}
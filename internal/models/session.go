@@ -8,9 +8,17 @@ import (
 
 type Session struct {
 	Base
-	UserID    uuid.UUID `db:"user_id" json:"user_id"`
-	Token     string    `db:"token" json:"token,omitempty"`
-	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
-	IPAddress string    `db:"ip_address" json:"ip_address"`
-	UserAgent string    `db:"user_agent" json:"user_agent"`
+	UserID           uuid.UUID  `db:"user_id" json:"user_id"`
+	FamilyID         uuid.UUID  `db:"family_id" json:"family_id"`
+	Token            string     `db:"token" json:"-"` // SHA-256 digest of the access token
+	ExpiresAt        time.Time  `db:"expires_at" json:"expires_at"`
+	RefreshToken     string     `db:"refresh_token" json:"-"` // SHA-256 digest of the refresh token
+	RefreshExpiresAt time.Time  `db:"refresh_expires_at" json:"refresh_expires_at"`
+	RotatedAt        *time.Time `db:"rotated_at" json:"rotated_at,omitempty"`
+	IPAddress        string     `db:"ip_address" json:"ip_address"`
+	UserAgent        string     `db:"user_agent" json:"user_agent"`
+	SignedInAt       time.Time  `db:"signed_in_at" json:"signed_in_at"`
+	LastSeenAt       time.Time  `db:"last_seen_at" json:"last_seen_at"`
+	LastIP           string     `db:"last_ip" json:"last_ip"`
+	IdleExpiresAt    *time.Time `db:"-" json:"idle_expires_at,omitempty"` // set when sessions expire after inactivity
 }
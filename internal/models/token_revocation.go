@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// TokenRevocation revokes every token matching Key that was issued at or
+// before RevokedBefore. Once ExpiresAt has passed those tokens have expired
+// on their own and the revocation can be forgotten.
+type TokenRevocation struct {
+	Key           string    `db:"key" json:"key"` // "jti:<id>", "session:<id>" or "user:<id>"
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+	RevokedBefore time.Time `db:"revoked_before" json:"revoked_before"`
+	ExpiresAt     time.Time `db:"expires_at" json:"expires_at"`
+	Reason        *string   `db:"reason" json:"reason,omitempty"`
+}
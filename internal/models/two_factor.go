@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UserTwoFactor struct {
+	ID           uuid.UUID  `db:"id" json:"id"` // using userID
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
+	Secret       string     `db:"secret" json:"-"`
+	ConfirmedAt  *time.Time `db:"confirmed_at" json:"confirmed_at,omitempty"`
+	LastUsedStep int64      `db:"last_used_step" json:"-"`
+}
+
+type UserRecoveryCode struct {
+	ID        uuid.UUID  `db:"id" json:"id"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UserID    uuid.UUID  `db:"user_id" json:"user_id"`
+	Code      string     `db:"code" json:"-"`
+	UsedAt    *time.Time `db:"used_at" json:"used_at,omitempty"`
+}
@@ -1,13 +1,37 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Upload struct {
 	Base
-	KeyFile   string    `db:"key_file" json:"key_file"`
-	FileName  string    `db:"file_name" json:"file_name"`
-	MimeType  string    `db:"mimetype" json:"mimetype"`
-	Size      int64     `db:"size" json:"size"`
-	SignedURL string    `db:"signed_url" json:"signed_url"`
-	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+	UserID         *uuid.UUID `db:"user_id" json:"user_id"`
+	KeyFile        string     `db:"key_file" json:"key_file"`
+	FileName       string     `db:"file_name" json:"file_name"`
+	MimeType       string     `db:"mimetype" json:"mimetype"`
+	Size           int64      `db:"size" json:"size"`
+	SignedURL      string     `db:"signed_url" json:"signed_url"`
+	ExpiresAt      time.Time  `db:"expires_at" json:"expires_at"`
+	ThumbnailSizes []int64    `db:"thumbnail_sizes" json:"-"`
+	// Thumbnails are download URLs by width, expiring with SignedURL.
+	Thumbnails map[string]string `db:"-" json:"thumbnails,omitempty"`
+}
+
+// ThumbnailKey is the object key of the thumbnail size pixels wide.
+func (entity *Upload) ThumbnailKey(size int64) string {
+	return fmt.Sprintf("%s_%d", entity.KeyFile, size)
+}
+
+// Keys returns the object keys of the file and its thumbnails.
+func (entity *Upload) Keys() []string {
+	keys := []string{entity.KeyFile}
+	for _, size := range entity.ThumbnailSizes {
+		keys = append(keys, entity.ThumbnailKey(size))
+	}
+
+	return keys
 }
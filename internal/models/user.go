@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"gintama/internal/lib/argon2"
@@ -24,9 +25,15 @@ type User struct {
 	Upload *Upload `json:"upload,omitempty"`
 }
 
-func (entity *User) BeforeCreate() (err error) {
-	hash := argon2.New()
+func (entity *User) FullName() string {
+	if entity.LastName == nil || *entity.LastName == "" {
+		return entity.FirstName
+	}
+
+	return strings.Join([]string{entity.FirstName, *entity.LastName}, " ")
+}
 
+func (entity *User) BeforeCreate(hash *argon2.Argon2) (err error) {
 	if entity.Password != nil {
 		password, err := hash.Generate(*entity.Password)
 		if err != nil {
@@ -40,7 +47,19 @@ func (entity *User) BeforeCreate() (err error) {
 }
 
 type UserVerifyAccount struct {
-	ID        uuid.UUID `db:"id" json:"id"` // using userID
-	Token     string    `db:"token" json:"token"`
-	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+	ID                  uuid.UUID `db:"id" json:"id"`   // using userID
+	Token               string    `db:"token" json:"-"` // SHA-256 digest of the verification token
+	ExpiresAt           time.Time `db:"expires_at" json:"expires_at"`
+	SentCount           int       `db:"sent_count" json:"sent_count"`
+	SentWindowStartedAt time.Time `db:"sent_window_started_at" json:"sent_window_started_at"`
+	LastSentAt          time.Time `db:"last_sent_at" json:"last_sent_at"`
+}
+
+type UserResetPassword struct {
+	ID                  uuid.UUID `db:"id" json:"id"` // using userID
+	Token               string    `db:"token" json:"-"`
+	ExpiresAt           time.Time `db:"expires_at" json:"expires_at"`
+	SentCount           int       `db:"sent_count" json:"sent_count"`
+	SentWindowStartedAt time.Time `db:"sent_window_started_at" json:"sent_window_started_at"`
+	LastSentAt          time.Time `db:"last_sent_at" json:"last_sent_at"`
 }
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"braces.dev/errtrace"
+)
+
+type AdvisoryLockRepository struct {
+	DB *sql.DB
+}
+
+// TryLock takes the Postgres advisory lock named name without waiting. The
+// lock belongs to a transaction kept open until unlock is called, so it is
+// also released when the connection is lost.
+func (r AdvisoryLockRepository) TryLock(name string) (func() error, bool, error) {
+	query := `
+		SELECT pg_try_advisory_xact_lock(hashtext($1));
+	`
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, false, errtrace.Wrap(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var ok bool
+	err = tx.QueryRowContext(ctx, query, name).Scan(&ok)
+	if err != nil {
+		tx.Rollback()
+		return nil, false, errtrace.Errorf("error scanning row: %w", err)
+	}
+
+	if !ok {
+		tx.Rollback()
+		return nil, false, nil
+	}
+
+	unlock := func() error {
+		return errtrace.Wrap(tx.Rollback())
+	}
+
+	return unlock, true, nil
+}
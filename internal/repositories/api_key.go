@@ -0,0 +1,297 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"gintama/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type APIKeyRepository struct {
+	DB *sql.DB
+}
+
+func (r APIKeyRepository) ListByUserID(userID uuid.UUID) ([]*models.APIKey, error) {
+	return r.listByUserIDExec(r.DB, userID)
+}
+
+func (r APIKeyRepository) listByUserIDExec(exc Executor, userID uuid.UUID) ([]*models.APIKey, error) {
+	query := `
+		SELECT "id", "created_at", "updated_at", "user_id", "name", "prefix", "scopes", "expires_at", "last_used_at"
+		FROM "api_keys"
+		WHERE "user_id" = $1
+		ORDER BY "created_at" DESC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errtrace.Errorf("error querying rows: %w", err)
+	}
+	defer rows.Close()
+
+	apiKeys := []*models.APIKey{}
+	for rows.Next() {
+		apiKey := &models.APIKey{}
+		if err := rows.Scan(
+			&apiKey.ID,
+			&apiKey.CreatedAt,
+			&apiKey.UpdatedAt,
+			&apiKey.UserID,
+			&apiKey.Name,
+			&apiKey.Prefix,
+			pq.Array(&apiKey.Scopes),
+			&apiKey.ExpiresAt,
+			&apiKey.LastUsedAt,
+		); err != nil {
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	return apiKeys, nil
+}
+
+// Get returns ErrRecordNotFound when the key does not exist or belongs to
+// another user.
+func (r APIKeyRepository) Get(userID uuid.UUID, id uuid.UUID) (*models.APIKey, error) {
+	return r.getExec(r.DB, userID, id)
+}
+
+func (r APIKeyRepository) getExec(exc Executor, userID uuid.UUID, id uuid.UUID) (*models.APIKey, error) {
+	query := `
+		SELECT "id", "created_at", "updated_at", "user_id", "name", "prefix", "scopes", "expires_at", "last_used_at"
+		FROM "api_keys"
+		WHERE "user_id" = $1 AND "id" = $2;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	apiKey := &models.APIKey{}
+	err := exc.QueryRowContext(ctx, query, userID, id).Scan(
+		&apiKey.ID,
+		&apiKey.CreatedAt,
+		&apiKey.UpdatedAt,
+		&apiKey.UserID,
+		&apiKey.Name,
+		&apiKey.Prefix,
+		pq.Array(&apiKey.Scopes),
+		&apiKey.ExpiresAt,
+		&apiKey.LastUsedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return apiKey, nil
+}
+
+// GetByKeyHash looks a key up by its digest. It never returns expired keys or
+// keys of blocked or deleted users.
+func (r APIKeyRepository) GetByKeyHash(keyHash string) (*models.APIKey, error) {
+	return r.getByKeyHashExec(r.DB, keyHash)
+}
+
+func (r APIKeyRepository) getByKeyHashExec(exc Executor, keyHash string) (*models.APIKey, error) {
+	query := `
+		SELECT "k"."id", "k"."created_at", "k"."updated_at", "k"."user_id", "k"."name", "k"."prefix", "k"."scopes", "k"."expires_at", "k"."last_used_at"
+		FROM "api_keys" "k"
+		INNER JOIN "users" "u" ON "u"."id" = "k"."user_id"
+		WHERE "k"."key_hash" = $1 AND
+				("k"."expires_at" IS NULL OR "k"."expires_at" > now()) AND
+				"u"."blocked_at" IS NULL AND
+				"u"."deleted_at" IS NULL;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	apiKey := &models.APIKey{}
+	err := exc.QueryRowContext(ctx, query, keyHash).Scan(
+		&apiKey.ID,
+		&apiKey.CreatedAt,
+		&apiKey.UpdatedAt,
+		&apiKey.UserID,
+		&apiKey.Name,
+		&apiKey.Prefix,
+		pq.Array(&apiKey.Scopes),
+		&apiKey.ExpiresAt,
+		&apiKey.LastUsedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return apiKey, nil
+}
+
+func (r APIKeyRepository) Insert(apiKey *models.APIKey) error {
+	return r.insertExec(r.DB, apiKey)
+}
+
+func (r APIKeyRepository) insertExec(exc Executor, apiKey *models.APIKey) error {
+	query := `
+		INSERT INTO "api_keys" ("id", "user_id", "name", "prefix", "key_hash", "scopes", "expires_at")
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING "created_at", "updated_at";
+	`
+
+	args := []any{
+		apiKey.ID,
+		apiKey.UserID,
+		apiKey.Name,
+		apiKey.Prefix,
+		apiKey.KeyHash,
+		pq.Array(apiKey.Scopes),
+		apiKey.ExpiresAt,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := exc.QueryRowContext(ctx, query, args...).Scan(&apiKey.CreatedAt, &apiKey.UpdatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "23505" {
+				return errtrace.Wrap(ErrInsertDuplicate)
+			}
+		}
+		return errtrace.Errorf("error scanning row: %w", err)
+	}
+
+	return nil
+}
+
+// Update changes the name, scopes and expiry of a key. It returns
+// ErrRecordNotFound when the key does not exist or belongs to another user.
+func (r APIKeyRepository) Update(apiKey *models.APIKey) error {
+	return r.updateExec(r.DB, apiKey)
+}
+
+func (r APIKeyRepository) updateExec(exc Executor, apiKey *models.APIKey) error {
+	query := `
+		UPDATE "api_keys"
+		SET "name" = $3, "scopes" = $4, "expires_at" = $5, "updated_at" = now()
+		WHERE "user_id" = $1 AND "id" = $2
+		RETURNING "updated_at";
+	`
+
+	args := []any{
+		apiKey.UserID,
+		apiKey.ID,
+		apiKey.Name,
+		pq.Array(apiKey.Scopes),
+		apiKey.ExpiresAt,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := exc.QueryRowContext(ctx, query, args...).Scan(&apiKey.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Touch records that the key was used.
+func (r APIKeyRepository) Touch(id uuid.UUID) error {
+	return r.touchExec(r.DB, id)
+}
+
+func (r APIKeyRepository) touchExec(exc Executor, id uuid.UUID) error {
+	query := `
+		UPDATE "api_keys"
+		SET "last_used_at" = now()
+		WHERE "id" = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := exc.ExecContext(ctx, query, id)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
+
+// Delete returns ErrRecordNotFound when the key does not exist or belongs to
+// another user.
+func (r APIKeyRepository) Delete(userID uuid.UUID, id uuid.UUID) error {
+	return r.deleteExec(r.DB, userID, id)
+}
+
+func (r APIKeyRepository) deleteExec(exc Executor, userID uuid.UUID, id uuid.UUID) error {
+	query := `
+		DELETE FROM "api_keys"
+		WHERE "user_id" = $1 AND "id" = $2;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, userID, id)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// DeleteByUserID revokes every key of a user.
+func (r APIKeyRepository) DeleteByUserID(userID uuid.UUID) error {
+	return r.DeleteByUserIDExec(r.DB, userID)
+}
+
+func (r APIKeyRepository) DeleteByUserIDExec(exc Executor, userID uuid.UUID) error {
+	query := `
+		DELETE FROM "api_keys"
+		WHERE "user_id" = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := exc.ExecContext(ctx, query, userID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
@@ -1,19 +1,65 @@
 package repositories
 
-import "database/sql"
+import (
+	"database/sql"
+	"time"
+
+	"gintama/internal/lib/argon2"
+)
 
 type Repositories struct {
 	Role              RoleRepository
+	Permission        PermissionRepository
+	RolePermission    RolePermissionRepository
 	User              UserRepository
 	UserVerifyAccount UserVerifyAccountRepository
+	UserResetPassword UserResetPasswordRepository
+	UserTwoFactor     UserTwoFactorRepository
+	UserRecoveryCode  UserRecoveryCodeRepository
 	Session           SessionRepository
+	LoginAttempt      LoginAttemptRepository
+	RateLimit         RateLimitRepository
+	OIDCState         OIDCStateRepository
+	UserIdentity      UserIdentityRepository
+	TokenRevocation   TokenRevocationRepository
+	AdvisoryLock      AdvisoryLockRepository
+	APIKey            APIKeyRepository
+	Upload            UploadRepository
+	ResumableUpload   ResumableUploadRepository
 }
 
 func New(db *sql.DB) Repositories {
 	return Repositories{
 		Role:              RoleRepository{BaseRepository: BaseRepository{DB: db, TableName: "roles"}},
-		User:              UserRepository{BaseRepository: BaseRepository{DB: db, TableName: "users"}},
+		Permission:        PermissionRepository{BaseRepository: BaseRepository{DB: db, TableName: "permissions"}},
+		RolePermission:    RolePermissionRepository{DB: db},
+		User:              UserRepository{BaseRepository: BaseRepository{DB: db, TableName: "users"}, Upload: UploadRepository{DB: db}, Password: argon2.New()},
 		UserVerifyAccount: UserVerifyAccountRepository{DB: db},
+		UserResetPassword: UserResetPasswordRepository{DB: db},
+		UserTwoFactor:     UserTwoFactorRepository{DB: db},
+		UserRecoveryCode:  UserRecoveryCodeRepository{DB: db},
 		Session:           SessionRepository{DB: db},
+		LoginAttempt:      LoginAttemptRepository{DB: db},
+		RateLimit:         RateLimitRepository{DB: db},
+		OIDCState:         OIDCStateRepository{DB: db},
+		UserIdentity:      UserIdentityRepository{DB: db},
+		TokenRevocation:   TokenRevocationRepository{DB: db},
+		AdvisoryLock:      AdvisoryLockRepository{DB: db},
+		APIKey:            APIKeyRepository{DB: db},
+		Upload:            UploadRepository{DB: db},
+		ResumableUpload:   ResumableUploadRepository{DB: db},
 	}
 }
+
+// UseURLSigner renews the download URLs of uploads with signer, including the
+// avatars read with users.
+func (r *Repositories) UseURLSigner(signer URLSigner, expiresIn time.Duration) {
+	r.Upload.Signer = signer
+	r.Upload.URLExpiresIn = expiresIn
+	r.User.Upload = r.Upload
+}
+
+// UsePasswordHasher hashes the passwords of inserted users with hash.
+func (r *Repositories) UsePasswordHasher(hash *argon2.Argon2) {
+	r.User.Password = hash
+}
@@ -0,0 +1,152 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"gintama/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/lib/pq"
+)
+
+type LoginAttemptRepository struct {
+	DB *sql.DB
+}
+
+func (r LoginAttemptRepository) GetByKeys(keys ...string) ([]*models.LoginAttempt, error) {
+	return r.getByKeysExec(r.DB, keys...)
+}
+
+func (r LoginAttemptRepository) getByKeysExec(exc Executor, keys ...string) ([]*models.LoginAttempt, error) {
+	query := `
+		SELECT "key", "created_at", "updated_at", "failures", "window_started_at", "last_failed_at", "locked_until"
+		FROM "login_attempts"
+		WHERE "key" = ANY($1);
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, pq.Array(keys))
+	if err != nil {
+		return nil, errtrace.Errorf("error querying rows: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []*models.LoginAttempt
+	for rows.Next() {
+		attempt := &models.LoginAttempt{}
+		if err := rows.Scan(
+			&attempt.Key,
+			&attempt.CreatedAt,
+			&attempt.UpdatedAt,
+			&attempt.Failures,
+			&attempt.WindowStartedAt,
+			&attempt.LastFailedAt,
+			&attempt.LockedUntil,
+		); err != nil {
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	return attempts, nil
+}
+
+// RecordFailure counts a failed attempt for the key in a single statement, so
+// concurrent failures across API instances are never lost. The count restarts
+// when the current window began before windowStart.
+func (r LoginAttemptRepository) RecordFailure(key string, now time.Time, windowStart time.Time) (*models.LoginAttempt, error) {
+	return r.recordFailureExec(r.DB, key, now, windowStart)
+}
+
+func (r LoginAttemptRepository) recordFailureExec(exc Executor, key string, now time.Time, windowStart time.Time) (*models.LoginAttempt, error) {
+	query := `
+		INSERT INTO "login_attempts" ("key", "failures", "window_started_at", "last_failed_at")
+		VALUES ($1, 1, $2, $2)
+		ON CONFLICT ("key") DO UPDATE
+		SET "failures" = CASE
+					WHEN "login_attempts"."window_started_at" < $3 THEN 1
+					ELSE "login_attempts"."failures" + 1
+				END,
+				"window_started_at" = CASE
+					WHEN "login_attempts"."window_started_at" < $3 THEN $2
+					ELSE "login_attempts"."window_started_at"
+				END,
+				"last_failed_at" = $2,
+				"updated_at" = now()
+		RETURNING "key", "created_at", "updated_at", "failures", "window_started_at", "last_failed_at", "locked_until";
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	attempt := &models.LoginAttempt{}
+	err := exc.QueryRowContext(ctx, query, key, now, windowStart).Scan(
+		&attempt.Key,
+		&attempt.CreatedAt,
+		&attempt.UpdatedAt,
+		&attempt.Failures,
+		&attempt.WindowStartedAt,
+		&attempt.LastFailedAt,
+		&attempt.LockedUntil,
+	)
+	if err != nil {
+		return nil, errtrace.Errorf("error scanning row: %w", err)
+	}
+
+	return attempt, nil
+}
+
+// Lock locks the key until the given time and restarts its failure count. It
+// reports false when the key was already locked, so a lockout is only
+// announced once.
+func (r LoginAttemptRepository) Lock(key string, until time.Time, now time.Time) (bool, error) {
+	return r.lockExec(r.DB, key, until, now)
+}
+
+func (r LoginAttemptRepository) lockExec(exc Executor, key string, until time.Time, now time.Time) (bool, error) {
+	query := `
+		UPDATE "login_attempts"
+		SET "locked_until" = $2, "failures" = 0, "window_started_at" = $3, "updated_at" = now()
+		WHERE "key" = $1 AND ("locked_until" IS NULL OR "locked_until" <= $3);
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, key, until, now)
+	if err != nil {
+		return false, errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r LoginAttemptRepository) Delete(keys ...string) error {
+	return r.deleteExec(r.DB, keys...)
+}
+
+func (r LoginAttemptRepository) deleteExec(exc Executor, keys ...string) error {
+	query := `
+		DELETE FROM "login_attempts"
+		WHERE "key" = ANY($1);
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := exc.ExecContext(ctx, query, pq.Array(keys))
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"gintama/internal/models"
+
+	"braces.dev/errtrace"
+)
+
+type OIDCStateRepository struct {
+	DB *sql.DB
+}
+
+func (r OIDCStateRepository) Insert(state *models.OIDCState) error {
+	return r.insertExec(r.DB, state)
+}
+
+func (r OIDCStateRepository) insertExec(exc Executor, state *models.OIDCState) error {
+	query := `
+		INSERT INTO "oidc_states" ("id", "provider", "nonce", "code_verifier", "expires_at")
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING "created_at";
+	`
+
+	args := []any{state.ID, state.Provider, state.Nonce, state.CodeVerifier, state.ExpiresAt}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := exc.QueryRowContext(ctx, query, args...).Scan(&state.CreatedAt)
+	if err != nil {
+		return errtrace.Errorf("error scanning row: %w", err)
+	}
+
+	return nil
+}
+
+// Consume deletes and returns the state so a callback can only be completed
+// once. It returns ErrRecordNotFound when the state is unknown or was used.
+func (r OIDCStateRepository) Consume(id string, provider string) (*models.OIDCState, error) {
+	return r.consumeExec(r.DB, id, provider)
+}
+
+func (r OIDCStateRepository) consumeExec(exc Executor, id string, provider string) (*models.OIDCState, error) {
+	query := `
+		DELETE FROM "oidc_states"
+		WHERE "id" = $1 AND "provider" = $2
+		RETURNING "id", "created_at", "provider", "nonce", "code_verifier", "expires_at";
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	state := &models.OIDCState{}
+	err := exc.QueryRowContext(ctx, query, id, provider).Scan(
+		&state.ID,
+		&state.CreatedAt,
+		&state.Provider,
+		&state.Nonce,
+		&state.CodeVerifier,
+		&state.ExpiresAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return state, nil
+}
+
+// DeleteExpired removes up to limit sign in states that expired before now.
+func (r OIDCStateRepository) DeleteExpired(now time.Time, limit int) (int64, error) {
+	return r.deleteExpiredExec(r.DB, now, limit)
+}
+
+func (r OIDCStateRepository) deleteExpiredExec(exc Executor, now time.Time, limit int) (int64, error) {
+	query := `
+		DELETE FROM "oidc_states"
+		WHERE "id" IN (
+			SELECT "id" FROM "oidc_states" WHERE "expires_at" <= $1 LIMIT $2
+		);
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, now, limit)
+	if err != nil {
+		return 0, errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
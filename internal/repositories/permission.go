@@ -0,0 +1,260 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gintama/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type PermissionRepository struct {
+	BaseRepository
+}
+
+func (r PermissionRepository) Count() (int64, error) {
+	return r.BaseRepository.countExec(r.DB)
+}
+
+func (r PermissionRepository) List(opts *QueryOptions) ([]*models.Permission, PaginationMetadata, error) {
+	return r.listExec(r.DB, opts)
+}
+
+func (r PermissionRepository) listExec(exc Executor, opts *QueryOptions) ([]*models.Permission, PaginationMetadata, error) {
+	selectFields := `"id", "name", "description", "created_at", "updated_at"`
+	baseQuery := fmt.Sprintf(`
+		SELECT %s
+		FROM "permissions"
+	`, selectFields)
+
+	var args []any
+	argIndex := 1
+
+	var queryBuilder strings.Builder
+	queryBuilder.WriteString(baseQuery)
+
+	orderBy := `"name"`
+	order := "ASC"
+
+	if opts.OrderBy != "" {
+		orderBy = opts.OrderBy
+	}
+
+	if opts.Order != "" {
+		upperOrder := strings.ToUpper(opts.Order)
+		if upperOrder != "ASC" && upperOrder != "DESC" {
+			return nil, PaginationMetadata{}, errtrace.New("invalid order")
+		}
+		order = upperOrder
+	}
+
+	queryBuilder.WriteString(fmt.Sprintf(" ORDER BY %s %s", orderBy, order))
+
+	if opts.Limit > 0 {
+		queryBuilder.WriteString(fmt.Sprintf(" LIMIT $%d", argIndex))
+		args = append(args, opts.Limit)
+		argIndex++
+	}
+
+	if opts.Offset > 0 {
+		queryBuilder.WriteString(fmt.Sprintf(" OFFSET $%d", argIndex))
+		args = append(args, opts.Offset)
+		argIndex++
+	}
+
+	query := queryBuilder.String()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, PaginationMetadata{}, errtrace.Wrap(err)
+	}
+	defer rows.Close()
+
+	var permissions []*models.Permission
+	for rows.Next() {
+		permission := &models.Permission{}
+		if err := rows.Scan(&permission.ID, &permission.Name, &permission.Description, &permission.CreatedAt, &permission.UpdatedAt); err != nil {
+			return nil, PaginationMetadata{}, errtrace.Errorf("error scanning row: %w", err)
+		}
+		permissions = append(permissions, permission)
+	}
+
+	count, err := r.Count()
+	if err != nil {
+		return nil, PaginationMetadata{}, errtrace.Wrap(err)
+	}
+
+	return permissions, PaginationMetadata{Total: count}, nil
+}
+
+func (r PermissionRepository) Get(id uuid.UUID) (*models.Permission, error) {
+	return r.getExec(r.DB, id)
+}
+
+func (r PermissionRepository) getExec(exc Executor, id uuid.UUID) (*models.Permission, error) {
+	query := `
+		SELECT "id", "name", "description", "created_at", "updated_at"
+		FROM "permissions"
+		WHERE "id" = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	permission := &models.Permission{}
+	err := exc.QueryRowContext(ctx, query, id).Scan(&permission.ID, &permission.Name, &permission.Description, &permission.CreatedAt, &permission.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return permission, nil
+}
+
+func (r PermissionRepository) GetByName(name string) (*models.Permission, error) {
+	return r.getByNameExec(r.DB, name)
+}
+
+func (r PermissionRepository) getByNameExec(exc Executor, name string) (*models.Permission, error) {
+	query := `
+		SELECT "id", "name", "description", "created_at", "updated_at"
+		FROM "permissions"
+		WHERE "name" = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	permission := &models.Permission{}
+	err := exc.QueryRowContext(ctx, query, name).Scan(&permission.ID, &permission.Name, &permission.Description, &permission.CreatedAt, &permission.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return permission, nil
+}
+
+func (r PermissionRepository) Insert(permissions ...*models.Permission) error {
+	return r.insertExec(r.DB, permissions...)
+}
+
+func (r PermissionRepository) insertExec(exc Executor, permissions ...*models.Permission) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	columns := []string{"id", "name", "description"}
+
+	valueStrings := make([]string, 0, len(permissions))
+	valueArgs := make([]any, 0, len(permissions)*len(columns))
+
+	for i, permission := range permissions {
+		values := []any{permission.ID, permission.Name, permission.Description}
+
+		placeholders := make([]string, 0, len(values))
+		for j := range columns {
+			placeholders = append(placeholders, "$"+strconv.Itoa(i*len(columns)+j+1))
+		}
+
+		valueStrings = append(valueStrings, fmt.Sprintf("(%s)", strings.Join(placeholders, ",")))
+		valueArgs = append(valueArgs, values...)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO "permissions" (%s)
+		VALUES %s
+		RETURNING "id", "created_at", "updated_at";
+	`, strings.Join(columns[:], ", "), strings.Join(valueStrings, ", "))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, valueArgs...)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "23505" {
+				return errtrace.Wrap(ErrInsertDuplicate)
+			}
+		}
+		return errtrace.Wrap(err)
+	}
+	defer rows.Close()
+
+	for _, permission := range permissions {
+		if !rows.Next() {
+			return errtrace.New("error scanning row: no next row")
+		}
+
+		if err := rows.Scan(&permission.ID, &permission.CreatedAt, &permission.UpdatedAt); err != nil {
+			return errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r PermissionRepository) Update(id uuid.UUID, permission *models.Permission) error {
+	return r.updateExec(r.DB, id, permission)
+}
+
+func (r PermissionRepository) updateExec(exc Executor, id uuid.UUID, permission *models.Permission) error {
+	query := `
+		UPDATE "permissions"
+		SET "name" = $1, "description" = $2, "updated_at" = now()
+		WHERE "id" = $3;
+	`
+
+	args := []any{
+		permission.Name,
+		permission.Description,
+		id,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, args...)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "23505" {
+				return errtrace.Wrap(ErrInsertDuplicate)
+			}
+		}
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
+	return nil
+}
+
+func (r PermissionRepository) Delete(id uuid.UUID) error {
+	return r.BaseRepository.deleteExec(r.DB, id)
+}
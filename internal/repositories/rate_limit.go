@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"braces.dev/errtrace"
+)
+
+// RateLimitRepository is the shared store of the rate limiter, used when the
+// API runs on several instances.
+type RateLimitRepository struct {
+	DB *sql.DB
+}
+
+// Increment counts a request against the key in a single statement and
+// starts a new window once the previous one has ended.
+func (r RateLimitRepository) Increment(key string, window time.Duration, now time.Time) (int, time.Time, error) {
+	return r.incrementExec(r.DB, key, window, now)
+}
+
+func (r RateLimitRepository) incrementExec(exc Executor, key string, window time.Duration, now time.Time) (int, time.Time, error) {
+	query := `
+		INSERT INTO "rate_limits" ("key", "count", "reset_at")
+		VALUES ($1, 1, $3)
+		ON CONFLICT ("key") DO UPDATE
+		SET "count" = CASE
+					WHEN "rate_limits"."reset_at" <= $2 THEN 1
+					ELSE "rate_limits"."count" + 1
+				END,
+				"reset_at" = CASE
+					WHEN "rate_limits"."reset_at" <= $2 THEN $3
+					ELSE "rate_limits"."reset_at"
+				END
+		RETURNING "count", "reset_at";
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var count int
+	var resetAt time.Time
+	err := exc.QueryRowContext(ctx, query, key, now, now.Add(window)).Scan(&count, &resetAt)
+	if err != nil {
+		return 0, time.Time{}, errtrace.Errorf("error scanning row: %w", err)
+	}
+
+	return count, resetAt, nil
+}
+
+// DeleteExpired removes up to limit counters whose window ended before now.
+func (r RateLimitRepository) DeleteExpired(now time.Time, limit int) (int64, error) {
+	return r.deleteExpiredExec(r.DB, now, limit)
+}
+
+func (r RateLimitRepository) deleteExpiredExec(exc Executor, now time.Time, limit int) (int64, error) {
+	query := `
+		DELETE FROM "rate_limits"
+		WHERE "key" IN (
+			SELECT "key" FROM "rate_limits" WHERE "reset_at" <= $1 LIMIT $2
+		);
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, now, limit)
+	if err != nil {
+		return 0, errtrace.Wrap(err)
+	}
+
+	return result.RowsAffected()
+}
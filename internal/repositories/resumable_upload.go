@@ -0,0 +1,307 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"gintama/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type ResumableUploadRepository struct {
+	DB *sql.DB
+}
+
+const resumableUploadFields = `"id", "created_at", "updated_at", "user_id", "key_file", "file_name", "mimetype", "size", "upload_offset", "multipart_id", "part_etags", "checksum", "hash_state", "locked_until", "expires_at"`
+
+func scanResumableUpload(row interface{ Scan(dest ...any) error }) (*models.ResumableUpload, error) {
+	upload := &models.ResumableUpload{}
+	err := row.Scan(
+		&upload.ID,
+		&upload.CreatedAt,
+		&upload.UpdatedAt,
+		&upload.UserID,
+		&upload.KeyFile,
+		&upload.FileName,
+		&upload.MimeType,
+		&upload.Size,
+		&upload.Offset,
+		&upload.MultipartID,
+		(*pq.StringArray)(&upload.PartETags),
+		&upload.Checksum,
+		&upload.HashState,
+		&upload.LockedUntil,
+		&upload.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+func (r ResumableUploadRepository) Insert(upload *models.ResumableUpload) error {
+	return r.insertExec(r.DB, upload)
+}
+
+func (r ResumableUploadRepository) insertExec(exc Executor, upload *models.ResumableUpload) error {
+	query := `
+		INSERT INTO "resumable_uploads" ("id", "user_id", "key_file", "file_name", "size", "checksum", "hash_state", "expires_at")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING "created_at", "updated_at";
+	`
+
+	args := []any{
+		upload.ID,
+		upload.UserID,
+		upload.KeyFile,
+		upload.FileName,
+		upload.Size,
+		upload.Checksum,
+		upload.HashState,
+		upload.ExpiresAt,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := exc.QueryRowContext(ctx, query, args...).Scan(&upload.CreatedAt, &upload.UpdatedAt)
+	if err != nil {
+		return errtrace.Errorf("error scanning row: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns ErrRecordNotFound when the upload does not exist, was made by
+// another user or was abandoned.
+func (r ResumableUploadRepository) Get(userID uuid.UUID, id uuid.UUID) (*models.ResumableUpload, error) {
+	return r.getExec(r.DB, userID, id)
+}
+
+func (r ResumableUploadRepository) getExec(exc Executor, userID uuid.UUID, id uuid.UUID) (*models.ResumableUpload, error) {
+	query := `
+		SELECT ` + resumableUploadFields + `
+		FROM "resumable_uploads"
+		WHERE "user_id" = $1 AND "id" = $2 AND "expires_at" > now();
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	upload, err := scanResumableUpload(exc.QueryRowContext(ctx, query, userID, id))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return upload, nil
+}
+
+// Lock reserves the upload for receiving the chunk at offset until until, so
+// concurrent requests cannot write the same part. It returns ErrEditConflict
+// when the upload moved past offset or another chunk is being received.
+func (r ResumableUploadRepository) Lock(upload *models.ResumableUpload, until time.Time) error {
+	query := `
+		UPDATE "resumable_uploads"
+		SET "locked_until" = $3
+		WHERE "id" = $1 AND "upload_offset" = $2 AND ("locked_until" IS NULL OR "locked_until" <= now());
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := r.DB.ExecContext(ctx, query, upload.ID, upload.Offset, until)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
+	upload.LockedUntil = &until
+	return nil
+}
+
+// Unlock releases a lock taken with Lock without recording a chunk.
+func (r ResumableUploadRepository) Unlock(id uuid.UUID) error {
+	query := `
+		UPDATE "resumable_uploads"
+		SET "locked_until" = NULL
+		WHERE "id" = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
+
+// Advance records a received chunk and releases the lock. It returns
+// ErrEditConflict when the upload is no longer at previousOffset.
+func (r ResumableUploadRepository) Advance(upload *models.ResumableUpload, previousOffset int64) error {
+	query := `
+		UPDATE "resumable_uploads"
+		SET "mimetype" = $3,
+				"upload_offset" = $4,
+				"multipart_id" = $5,
+				"part_etags" = $6,
+				"hash_state" = $7,
+				"expires_at" = $8,
+				"locked_until" = NULL,
+				"updated_at" = now()
+		WHERE "id" = $1 AND "upload_offset" = $2
+		RETURNING "updated_at";
+	`
+
+	args := []any{
+		upload.ID,
+		previousOffset,
+		upload.MimeType,
+		upload.Offset,
+		upload.MultipartID,
+		pq.Array(upload.PartETags),
+		upload.HashState,
+		upload.ExpiresAt,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.DB.QueryRowContext(ctx, query, args...).Scan(&upload.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	upload.LockedUntil = nil
+	return nil
+}
+
+// Delete removes the row and returns it, so the caller can abort the parts
+// in storage. It returns ErrRecordNotFound when the upload does not exist or
+// was made by another user.
+func (r ResumableUploadRepository) Delete(userID uuid.UUID, id uuid.UUID) (*models.ResumableUpload, error) {
+	return r.DeleteExec(r.DB, userID, id)
+}
+
+func (r ResumableUploadRepository) DeleteExec(exc Executor, userID uuid.UUID, id uuid.UUID) (*models.ResumableUpload, error) {
+	query := `
+		DELETE FROM "resumable_uploads"
+		WHERE "user_id" = $1 AND "id" = $2
+		RETURNING ` + resumableUploadFields + `;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	upload, err := scanResumableUpload(exc.QueryRowContext(ctx, query, userID, id))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return upload, nil
+}
+
+// ListExpired returns up to limit uploads abandoned before now, so the caller
+// can abort their parts in storage before deleting them with DeleteExpired.
+// Uploads receiving a chunk are kept until their lock expires.
+func (r ResumableUploadRepository) ListExpired(now time.Time, limit int) ([]*models.ResumableUpload, error) {
+	return r.listExpiredExec(r.DB, now, limit)
+}
+
+func (r ResumableUploadRepository) listExpiredExec(exc Executor, now time.Time, limit int) ([]*models.ResumableUpload, error) {
+	query := `
+		SELECT ` + resumableUploadFields + `
+		FROM "resumable_uploads"
+		WHERE "expires_at" <= $1 AND ("locked_until" IS NULL OR "locked_until" <= $1)
+		ORDER BY "expires_at" ASC
+		LIMIT $2;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, now, limit)
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+	defer rows.Close()
+
+	var uploads []*models.ResumableUpload
+	for rows.Next() {
+		upload, err := scanResumableUpload(rows)
+		if err != nil {
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+
+		uploads = append(uploads, upload)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	return uploads, nil
+}
+
+// DeleteExpired removes an upload listed by ListExpired. It returns
+// ErrRecordNotFound when the upload is gone or no longer abandoned at now.
+func (r ResumableUploadRepository) DeleteExpired(id uuid.UUID, now time.Time) error {
+	return r.deleteExpiredExec(r.DB, id, now)
+}
+
+func (r ResumableUploadRepository) deleteExpiredExec(exc Executor, id uuid.UUID, now time.Time) error {
+	query := `
+		DELETE FROM "resumable_uploads"
+		WHERE "id" = $1 AND "expires_at" <= $2 AND ("locked_until" IS NULL OR "locked_until" <= $2);
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, id, now)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"gintama/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type RolePermissionRepository struct {
+	DB *sql.DB
+}
+
+func (r RolePermissionRepository) ListByRoleID(roleID uuid.UUID) ([]*models.Permission, error) {
+	return r.listByRoleIDExec(r.DB, roleID)
+}
+
+func (r RolePermissionRepository) listByRoleIDExec(exc Executor, roleID uuid.UUID) ([]*models.Permission, error) {
+	query := `
+		SELECT "p"."id", "p"."name", "p"."description", "p"."created_at", "p"."updated_at"
+		FROM "permissions" "p"
+		INNER JOIN "role_permissions" "rp" ON "rp"."permission_id" = "p"."id"
+		WHERE "rp"."role_id" = $1
+		ORDER BY "p"."name" ASC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, roleID)
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+	defer rows.Close()
+
+	permissions := []*models.Permission{}
+	for rows.Next() {
+		permission := &models.Permission{}
+		if err := rows.Scan(&permission.ID, &permission.Name, &permission.Description, &permission.CreatedAt, &permission.UpdatedAt); err != nil {
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, nil
+}
+
+// UserHas reports whether the role of the user grants the permission. Users
+// whose role was soft deleted have no permissions.
+func (r RolePermissionRepository) UserHas(userID uuid.UUID, permission string) (bool, error) {
+	return r.userHasExec(r.DB, userID, permission)
+}
+
+func (r RolePermissionRepository) userHasExec(exc Executor, userID uuid.UUID, permission string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM "users" "u"
+			INNER JOIN "roles" "r" ON "r"."id" = "u"."role_id" AND "r"."deleted_at" IS NULL
+			INNER JOIN "role_permissions" "rp" ON "rp"."role_id" = "r"."id"
+			INNER JOIN "permissions" "p" ON "p"."id" = "rp"."permission_id"
+			WHERE "u"."id" = $1 AND "p"."name" = $2
+		);
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var exists bool
+	err := exc.QueryRowContext(ctx, query, userID, permission).Scan(&exists)
+	if err != nil {
+		return false, errtrace.Errorf("error scanning row: %w", err)
+	}
+
+	return exists, nil
+}
+
+// ListNamesByUserID returns the names of the permissions the role of the user
+// grants, sorted. Users whose role was soft deleted have no permissions.
+func (r RolePermissionRepository) ListNamesByUserID(userID uuid.UUID) ([]string, error) {
+	return r.listNamesByUserIDExec(r.DB, userID)
+}
+
+func (r RolePermissionRepository) listNamesByUserIDExec(exc Executor, userID uuid.UUID) ([]string, error) {
+	query := `
+		SELECT "p"."name"
+		FROM "users" "u"
+		INNER JOIN "roles" "r" ON "r"."id" = "u"."role_id" AND "r"."deleted_at" IS NULL
+		INNER JOIN "role_permissions" "rp" ON "rp"."role_id" = "r"."id"
+		INNER JOIN "permissions" "p" ON "p"."id" = "rp"."permission_id"
+		WHERE "u"."id" = $1
+		ORDER BY "p"."name" ASC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// Attach grants the permission to the role. Granting it twice is a no-op.
+// It returns ErrRecordNotFound when the role or the permission does not exist.
+func (r RolePermissionRepository) Attach(roleID uuid.UUID, permissionID uuid.UUID) error {
+	return r.attachExec(r.DB, roleID, permissionID)
+}
+
+func (r RolePermissionRepository) attachExec(exc Executor, roleID uuid.UUID, permissionID uuid.UUID) error {
+	query := `
+		INSERT INTO "role_permissions" ("role_id", "permission_id")
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := exc.ExecContext(ctx, query, roleID, permissionID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "23503" {
+				return errtrace.Wrap(ErrRecordNotFound)
+			}
+		}
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
+
+func (r RolePermissionRepository) Detach(roleID uuid.UUID, permissionID uuid.UUID) error {
+	return r.detachExec(r.DB, roleID, permissionID)
+}
+
+func (r RolePermissionRepository) detachExec(exc Executor, roleID uuid.UUID, permissionID uuid.UUID) error {
+	query := `
+		DELETE FROM "role_permissions"
+		WHERE "role_id" = $1 AND "permission_id" = $2;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, roleID, permissionID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -51,7 +51,7 @@ func (r SessionRepository) listExec(exc Executor, opts *QueryOptions) ([]*models
 		opts = &QueryOptions{}
 	}
 
-	selectFields := `"s"."id", "s"."created_at", "s"."updated_at", "s"."user_id", "s"."expires_at", "s"."ip_address", "s"."user_agent"`
+	selectFields := `"s"."id", "s"."created_at", "s"."updated_at", "s"."user_id", "s"."family_id", "s"."expires_at", "s"."refresh_expires_at", "s"."rotated_at", "s"."ip_address", "s"."user_agent", "s"."signed_in_at", "s"."last_seen_at", "s"."last_ip"`
 	baseQuery := fmt.Sprintf(`
 		SELECT %s
 		FROM "sessions" "s"
@@ -111,9 +111,15 @@ func (r SessionRepository) listExec(exc Executor, opts *QueryOptions) ([]*models
 			&session.CreatedAt,
 			&session.UpdatedAt,
 			&session.UserID,
+			&session.FamilyID,
 			&session.ExpiresAt,
+			&session.RefreshExpiresAt,
+			&session.RotatedAt,
 			&session.IPAddress,
 			&session.UserAgent,
+			&session.SignedInAt,
+			&session.LastSeenAt,
+			&session.LastIP,
 		); err != nil {
 			return nil, PaginationMetadata{}, errtrace.Errorf("error scanning row: %w", err)
 		}
@@ -130,63 +136,92 @@ func (r SessionRepository) listExec(exc Executor, opts *QueryOptions) ([]*models
 	}, nil
 }
 
-func (r SessionRepository) GetByUserID(userID uuid.UUID) (*models.Session, error) {
-	return r.getByUserIDExec(r.DB, userID)
+// ListByUserID returns one session per signed-in device of the user, skipping
+// rotated and expired sessions and those not seen since idleSince.
+func (r SessionRepository) ListByUserID(userID uuid.UUID, idleSince time.Time) ([]*models.Session, error) {
+	return r.listByUserIDExec(r.DB, userID, idleSince)
 }
 
-func (r SessionRepository) getByUserIDExec(exc Executor, userID uuid.UUID) (*models.Session, error) {
+func (r SessionRepository) listByUserIDExec(exc Executor, userID uuid.UUID, idleSince time.Time) ([]*models.Session, error) {
 	query := `
-		SELECT "id", "user_id", "token", "expires_at"
+		SELECT "id", "created_at", "updated_at", "user_id", "family_id", "expires_at", "refresh_expires_at", "ip_address", "user_agent", "signed_in_at", "last_seen_at", "last_ip"
 		FROM "sessions"
-		WHERE "user_id" = $1;
+		WHERE "user_id" = $1 AND
+				"rotated_at" IS NULL AND
+				"refresh_expires_at" > now() AND
+				"last_seen_at" > $2
+		ORDER BY "last_seen_at" DESC;
 	`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	session := &models.Session{}
-	err := exc.QueryRowContext(ctx, query, userID).Scan(
-		&session.ID,
-		&session.UserID,
-		&session.Token,
-		&session.ExpiresAt,
-	)
+	rows, err := exc.QueryContext(ctx, query, userID, idleSince)
 	if err != nil {
-		switch {
-		case errors.Is(err, sql.ErrNoRows):
-			return nil, ErrRecordNotFound
-		default:
+		return nil, errtrace.Errorf("error querying rows: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []*models.Session{}
+	for rows.Next() {
+		session := &models.Session{}
+		if err := rows.Scan(
+			&session.ID,
+			&session.CreatedAt,
+			&session.UpdatedAt,
+			&session.UserID,
+			&session.FamilyID,
+			&session.ExpiresAt,
+			&session.RefreshExpiresAt,
+			&session.IPAddress,
+			&session.UserAgent,
+			&session.SignedInAt,
+			&session.LastSeenAt,
+			&session.LastIP,
+		); err != nil {
 			return nil, errtrace.Errorf("error scanning row: %w", err)
 		}
+		sessions = append(sessions, session)
 	}
 
-	return session, nil
+	return sessions, nil
 }
 
-func (r SessionRepository) GetByToken(token string) (*models.Session, error) {
-	return r.getByTokenExec(r.DB, token)
+// GetByRefreshToken looks a session up by the digest of its refresh token. It
+// also returns rotated sessions, so callers can detect a refresh token that has
+// already been used.
+func (r SessionRepository) GetByRefreshToken(refreshToken string) (*models.Session, error) {
+	return r.getByRefreshTokenExec(r.DB, refreshToken)
 }
 
-func (r SessionRepository) getByTokenExec(exc Executor, token string) (*models.Session, error) {
+func (r SessionRepository) getByRefreshTokenExec(exc Executor, refreshToken string) (*models.Session, error) {
 	query := `
-		SELECT "s"."id", "s"."created_at", "s"."updated_at", "s"."user_id", "s"."token", "s"."expires_at", "s"."ip_address", "s"."user_agent"
+		SELECT "s"."id", "s"."created_at", "s"."updated_at", "s"."user_id", "s"."family_id", "s"."expires_at", "s"."refresh_token", "s"."refresh_expires_at", "s"."rotated_at", "s"."ip_address", "s"."user_agent", "s"."signed_in_at", "s"."last_seen_at"
 		FROM "sessions" "s"
-		WHERE "s"."token" = $1 AND "s"."expires_at" > now();
+		INNER JOIN "users" "u" ON "u"."id" = "s"."user_id"
+		WHERE "s"."refresh_token" = $1 AND
+				"u"."blocked_at" IS NULL AND
+				"u"."deleted_at" IS NULL;
 	`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
 	session := &models.Session{}
-	err := exc.QueryRowContext(ctx, query, token).Scan(
+	err := exc.QueryRowContext(ctx, query, refreshToken).Scan(
 		&session.ID,
 		&session.CreatedAt,
 		&session.UpdatedAt,
 		&session.UserID,
-		&session.Token,
+		&session.FamilyID,
 		&session.ExpiresAt,
+		&session.RefreshToken,
+		&session.RefreshExpiresAt,
+		&session.RotatedAt,
 		&session.IPAddress,
 		&session.UserAgent,
+		&session.SignedInAt,
+		&session.LastSeenAt,
 	)
 	if err != nil {
 		switch {
@@ -201,21 +236,21 @@ func (r SessionRepository) getByTokenExec(exc Executor, token string) (*models.S
 }
 
 func (r SessionRepository) Insert(session ...*models.Session) error {
-	return r.insertExec(r.DB, session...)
+	return r.InsertExec(r.DB, session...)
 }
 
-func (r SessionRepository) insertExec(exc Executor, session ...*models.Session) error {
+func (r SessionRepository) InsertExec(exc Executor, session ...*models.Session) error {
 	if len(session) == 0 {
 		return nil
 	}
 
-	columns := []string{"id", "user_id", "token", "expires_at", "ip_address", "user_agent"}
+	columns := []string{"id", "user_id", "family_id", "token", "expires_at", "refresh_token", "refresh_expires_at", "ip_address", "user_agent", "signed_in_at", "last_seen_at", "last_ip"}
 
 	valueStrings := make([]string, 0, len(session))
 	valueArgs := make([]any, 0, len(session)*len(columns))
 
 	for i, s := range session {
-		values := []any{s.ID, s.UserID, s.Token, s.ExpiresAt, s.IPAddress, s.UserAgent}
+		values := []any{s.ID, s.UserID, s.FamilyID, s.Token, s.ExpiresAt, s.RefreshToken, s.RefreshExpiresAt, s.IPAddress, s.UserAgent, s.SignedInAt, s.LastSeenAt, s.LastIP}
 
 		placeholders := make([]string, 0, len(values))
 		for j := range columns {
@@ -259,25 +294,239 @@ func (r SessionRepository) insertExec(exc Executor, session ...*models.Session)
 	return nil
 }
 
-func (r SessionRepository) Delete(userID uuid.UUID, token string) error {
-	return r.deleteExec(r.DB, userID, token)
+// Rotate marks a session as exchanged for a new one. It returns ErrEditConflict
+// when the session was already rotated, e.g. by a concurrent refresh.
+func (r SessionRepository) Rotate(id uuid.UUID) error {
+	return r.RotateExec(r.DB, id)
 }
 
-func (r SessionRepository) deleteExec(exc Executor, userID uuid.UUID, token string) error {
+func (r SessionRepository) RotateExec(exc Executor, id uuid.UUID) error {
+	query := `
+		UPDATE "sessions"
+		SET "rotated_at" = now(), "updated_at" = now()
+		WHERE "id" = $1 AND "rotated_at" IS NULL;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, id)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
+	return nil
+}
+
+// Touch records activity on a session. It returns ErrRecordNotFound when the
+// session does not exist, was rotated or has not been seen since idleSince.
+func (r SessionRepository) Touch(id uuid.UUID, ip string, idleSince time.Time) error {
+	return r.touchExec(r.DB, id, ip, idleSince)
+}
+
+func (r SessionRepository) touchExec(exc Executor, id uuid.UUID, ip string, idleSince time.Time) error {
+	query := `
+		UPDATE "sessions"
+		SET "last_seen_at" = now(), "last_ip" = $2
+		WHERE "id" = $1 AND "rotated_at" IS NULL AND "last_seen_at" > $3;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, id, ip, idleSince)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r SessionRepository) DeleteByFamilyID(familyID uuid.UUID) error {
+	return r.deleteByFamilyIDExec(r.DB, familyID)
+}
+
+func (r SessionRepository) deleteByFamilyIDExec(exc Executor, familyID uuid.UUID) error {
 	query := `
 		DELETE FROM "sessions"
-		WHERE "user_id" = $1 AND "token" = $2;
+		WHERE "family_id" = $1;
 	`
 
-	args := []any{userID, token}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := exc.ExecContext(ctx, query, familyID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
+
+// DeleteByUserID revokes every session of a user.
+func (r SessionRepository) DeleteByUserID(userID uuid.UUID) error {
+	return r.DeleteByUserIDExec(r.DB, userID)
+}
+
+func (r SessionRepository) DeleteByUserIDExec(exc Executor, userID uuid.UUID) error {
+	query := `
+		DELETE FROM "sessions"
+		WHERE "user_id" = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := exc.ExecContext(ctx, query, userID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
+
+// DeleteBySessionID revokes the sign-in the session belongs to. It returns
+// ErrRecordNotFound when the session does not exist or belongs to another user.
+func (r SessionRepository) DeleteBySessionID(userID uuid.UUID, sessionID uuid.UUID) error {
+	return r.deleteBySessionIDExec(r.DB, userID, sessionID)
+}
+
+func (r SessionRepository) deleteBySessionIDExec(exc Executor, userID uuid.UUID, sessionID uuid.UUID) error {
+	query := `
+		DELETE FROM "sessions"
+		WHERE "user_id" = $1 AND "family_id" IN (
+			SELECT "family_id" FROM "sessions" WHERE "user_id" = $1 AND "id" = $2
+		);
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, userID, sessionID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// DeleteFamilyBySessionID revokes the sign-in the session belongs to,
+// whoever owns it. It returns ErrRecordNotFound when the session does not exist.
+func (r SessionRepository) DeleteFamilyBySessionID(sessionID uuid.UUID) error {
+	return r.deleteFamilyBySessionIDExec(r.DB, sessionID)
+}
+
+func (r SessionRepository) deleteFamilyBySessionIDExec(exc Executor, sessionID uuid.UUID) error {
+	query := `
+		DELETE FROM "sessions"
+		WHERE "family_id" IN (
+			SELECT "family_id" FROM "sessions" WHERE "id" = $1
+		);
+	`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	_, err := exc.ExecContext(ctx, query, args...)
+	result, err := exc.ExecContext(ctx, query, sessionID)
 	if err != nil {
 		return errtrace.Wrap(err)
 	}
 
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
 	return nil
 }
+
+// DeleteOthers revokes every sign-in of the user except the one the session
+// belongs to.
+func (r SessionRepository) DeleteOthers(userID uuid.UUID, sessionID uuid.UUID) error {
+	return r.DeleteOthersExec(r.DB, userID, sessionID)
+}
+
+func (r SessionRepository) DeleteOthersExec(exc Executor, userID uuid.UUID, sessionID uuid.UUID) error {
+	query := `
+		DELETE FROM "sessions"
+		WHERE "user_id" = $1 AND "family_id" NOT IN (
+			SELECT "family_id" FROM "sessions" WHERE "user_id" = $1 AND "id" = $2
+		);
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := exc.ExecContext(ctx, query, userID, sessionID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes up to limit sessions that can no longer be refreshed,
+// because the refresh token expired before now or the session was not seen
+// since idleSince. Rotated sessions are kept until they expire to detect
+// reused refresh tokens.
+func (r SessionRepository) DeleteExpired(now time.Time, idleSince time.Time, limit int) (int64, error) {
+	return r.deleteExpiredExec(r.DB, now, idleSince, limit)
+}
+
+func (r SessionRepository) deleteExpiredExec(exc Executor, now time.Time, idleSince time.Time, limit int) (int64, error) {
+	query := `
+		DELETE FROM "sessions"
+		WHERE "id" IN (
+			SELECT "id" FROM "sessions"
+			WHERE "refresh_expires_at" <= $1 OR
+					("rotated_at" IS NULL AND "last_seen_at" <= $2)
+			LIMIT $3
+		);
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, now, idleSince, limit)
+	if err != nil {
+		return 0, errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
@@ -0,0 +1,224 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gintama/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/lib/pq"
+)
+
+// TokenRevocationRepository is the source of truth of the in-memory
+// revocation list every instance syncs from.
+type TokenRevocationRepository struct {
+	DB *sql.DB
+}
+
+// Insert stores revocations. Revoking a key again keeps the latest
+// revoked_before and expires_at.
+func (r TokenRevocationRepository) Insert(revocations ...*models.TokenRevocation) error {
+	return r.InsertExec(r.DB, revocations...)
+}
+
+func (r TokenRevocationRepository) InsertExec(exc Executor, revocations ...*models.TokenRevocation) error {
+	if len(revocations) == 0 {
+		return nil
+	}
+
+	columns := []string{"key", "revoked_before", "expires_at", "reason"}
+
+	valueStrings := make([]string, 0, len(revocations))
+	valueArgs := make([]any, 0, len(revocations)*len(columns))
+
+	for i, revocation := range revocations {
+		values := []any{revocation.Key, revocation.RevokedBefore, revocation.ExpiresAt, revocation.Reason}
+
+		placeholders := make([]string, 0, len(values))
+		for j := range columns {
+			placeholders = append(placeholders, "$"+strconv.Itoa(i*len(columns)+j+1))
+		}
+
+		valueStrings = append(valueStrings, fmt.Sprintf("(%s)", strings.Join(placeholders, ", ")))
+		valueArgs = append(valueArgs, values...)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO "token_revocations" (%s)
+		VALUES %s
+		ON CONFLICT ("key") DO UPDATE
+		SET "revoked_before" = GREATEST("token_revocations"."revoked_before", EXCLUDED."revoked_before"),
+				"expires_at" = GREATEST("token_revocations"."expires_at", EXCLUDED."expires_at"),
+				"reason" = EXCLUDED."reason",
+				"updated_at" = now()
+		RETURNING "created_at", "updated_at";
+	`, strings.Join(columns, ", "), strings.Join(valueStrings, ", "))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, valueArgs...)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+	defer rows.Close()
+
+	for _, revocation := range revocations {
+		if !rows.Next() {
+			return errtrace.New("error scanning row: no next row")
+		}
+
+		if err := rows.Scan(&revocation.CreatedAt, &revocation.UpdatedAt); err != nil {
+			return errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ConsumeExec inserts the revocation of a single-use token. It returns
+// ErrInsertDuplicate when the key is already revoked, so a token can only be
+// consumed once.
+func (r TokenRevocationRepository) ConsumeExec(exc Executor, revocation *models.TokenRevocation) error {
+	query := `
+		INSERT INTO "token_revocations" ("key", "revoked_before", "expires_at", "reason")
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT ("key") DO NOTHING
+		RETURNING "created_at", "updated_at";
+	`
+
+	args := []any{revocation.Key, revocation.RevokedBefore, revocation.ExpiresAt, revocation.Reason}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := exc.QueryRowContext(ctx, query, args...).Scan(&revocation.CreatedAt, &revocation.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return errtrace.Wrap(ErrInsertDuplicate)
+		default:
+			return errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Get returns the revocations of the given keys, skipping keys that were
+// never revoked.
+func (r TokenRevocationRepository) Get(keys ...string) ([]*models.TokenRevocation, error) {
+	return r.getExec(r.DB, keys...)
+}
+
+func (r TokenRevocationRepository) getExec(exc Executor, keys ...string) ([]*models.TokenRevocation, error) {
+	query := `
+		SELECT "key", "created_at", "updated_at", "revoked_before", "expires_at", "reason"
+		FROM "token_revocations"
+		WHERE "key" = ANY($1);
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, pq.Array(keys))
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+	defer rows.Close()
+
+	return r.scanRows(rows)
+}
+
+// Changed returns the revocations inserted or updated after since, together
+// with the latest updated_at to pass on the next call.
+func (r TokenRevocationRepository) Changed(since time.Time) ([]*models.TokenRevocation, time.Time, error) {
+	return r.changedExec(r.DB, since)
+}
+
+func (r TokenRevocationRepository) changedExec(exc Executor, since time.Time) ([]*models.TokenRevocation, time.Time, error) {
+	query := `
+		SELECT "key", "created_at", "updated_at", "revoked_before", "expires_at", "reason"
+		FROM "token_revocations"
+		WHERE "updated_at" > $1
+		ORDER BY "updated_at" ASC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, time.Time{}, errtrace.Wrap(err)
+	}
+	defer rows.Close()
+
+	revocations, err := r.scanRows(rows)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	watermark := since
+	if len(revocations) > 0 {
+		watermark = revocations[len(revocations)-1].UpdatedAt
+	}
+
+	return revocations, watermark, nil
+}
+
+// DeleteExpired removes up to limit revocations whose tokens have all expired.
+func (r TokenRevocationRepository) DeleteExpired(now time.Time, limit int) (int64, error) {
+	return r.deleteExpiredExec(r.DB, now, limit)
+}
+
+func (r TokenRevocationRepository) deleteExpiredExec(exc Executor, now time.Time, limit int) (int64, error) {
+	query := `
+		DELETE FROM "token_revocations"
+		WHERE "key" IN (
+			SELECT "key" FROM "token_revocations" WHERE "expires_at" <= $1 LIMIT $2
+		);
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, now, limit)
+	if err != nil {
+		return 0, errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+func (r TokenRevocationRepository) scanRows(rows *sql.Rows) ([]*models.TokenRevocation, error) {
+	revocations := []*models.TokenRevocation{}
+	for rows.Next() {
+		revocation := &models.TokenRevocation{}
+		err := rows.Scan(
+			&revocation.Key,
+			&revocation.CreatedAt,
+			&revocation.UpdatedAt,
+			&revocation.RevokedBefore,
+			&revocation.ExpiresAt,
+			&revocation.Reason,
+		)
+		if err != nil {
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+
+		revocations = append(revocations, revocation)
+	}
+
+	return revocations, nil
+}
@@ -0,0 +1,295 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"gintama/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// URLSigner makes download URLs for stored objects.
+type URLSigner interface {
+	SignedURL(key string, expiresIn time.Duration) (string, time.Time, error)
+}
+
+// signedURLRenewBefore is the least validity a returned download URL has left.
+const signedURLRenewBefore = time.Minute
+
+// UploadRepository renews the download URL of uploads it reads or inserts
+// with Signer once it expires. Without Signer the stored URL is returned as is.
+type UploadRepository struct {
+	DB           *sql.DB
+	Signer       URLSigner
+	URLExpiresIn time.Duration
+}
+
+// Get returns ErrRecordNotFound when the upload does not exist or was made by
+// another user.
+func (r UploadRepository) Get(userID uuid.UUID, id uuid.UUID) (*models.Upload, error) {
+	return r.getExec(r.DB, userID, id)
+}
+
+func (r UploadRepository) getExec(exc Executor, userID uuid.UUID, id uuid.UUID) (*models.Upload, error) {
+	query := `
+		SELECT "id", "created_at", "updated_at", "user_id", "key_file", "file_name", "mimetype", "size", "signed_url", "expires_at", "thumbnail_sizes"
+		FROM "uploads"
+		WHERE "user_id" = $1 AND "id" = $2 AND "deleted_at" IS NULL;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	upload := &models.Upload{}
+	err := exc.QueryRowContext(ctx, query, userID, id).Scan(
+		&upload.ID,
+		&upload.CreatedAt,
+		&upload.UpdatedAt,
+		&upload.UserID,
+		&upload.KeyFile,
+		&upload.FileName,
+		&upload.MimeType,
+		&upload.Size,
+		&upload.SignedURL,
+		&upload.ExpiresAt,
+		(*pq.Int64Array)(&upload.ThumbnailSizes),
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	err = r.renewSignedURLExec(exc, upload)
+	if err != nil {
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+func (r UploadRepository) Insert(upload *models.Upload) error {
+	return r.InsertExec(r.DB, upload)
+}
+
+func (r UploadRepository) InsertExec(exc Executor, upload *models.Upload) error {
+	_, err := r.signURL(upload)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO "uploads" ("id", "user_id", "key_file", "file_name", "mimetype", "size", "signed_url", "expires_at", "thumbnail_sizes")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING "created_at", "updated_at";
+	`
+
+	args := []any{
+		upload.ID,
+		upload.UserID,
+		upload.KeyFile,
+		upload.FileName,
+		upload.MimeType,
+		upload.Size,
+		upload.SignedURL,
+		upload.ExpiresAt,
+		pq.Array(upload.ThumbnailSizes),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err = exc.QueryRowContext(ctx, query, args...).Scan(&upload.CreatedAt, &upload.UpdatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "23505" {
+				return errtrace.Wrap(ErrInsertDuplicate)
+			}
+		}
+		return errtrace.Errorf("error scanning row: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the row of the upload and returns it, so the caller can
+// remove the stored object. Users showing it as avatar are unlinked by the
+// foreign key. It returns ErrRecordNotFound when the upload does not exist or
+// was made by another user.
+func (r UploadRepository) Delete(userID uuid.UUID, id uuid.UUID) (*models.Upload, error) {
+	return r.deleteExec(r.DB, userID, id)
+}
+
+func (r UploadRepository) deleteExec(exc Executor, userID uuid.UUID, id uuid.UUID) (*models.Upload, error) {
+	query := `
+		DELETE FROM "uploads"
+		WHERE "user_id" = $1 AND "id" = $2
+		RETURNING "id", "created_at", "updated_at", "user_id", "key_file", "file_name", "mimetype", "size", "signed_url", "expires_at", "thumbnail_sizes";
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	upload := &models.Upload{}
+	err := exc.QueryRowContext(ctx, query, userID, id).Scan(
+		&upload.ID,
+		&upload.CreatedAt,
+		&upload.UpdatedAt,
+		&upload.UserID,
+		&upload.KeyFile,
+		&upload.FileName,
+		&upload.MimeType,
+		&upload.Size,
+		&upload.SignedURL,
+		&upload.ExpiresAt,
+		(*pq.Int64Array)(&upload.ThumbnailSizes),
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return upload, nil
+}
+
+// RenewSignedURL gives upload a fresh download URL when its URL expires
+// within signedURLRenewBefore, and stores it.
+func (r UploadRepository) RenewSignedURL(upload *models.Upload) error {
+	return r.renewSignedURLExec(r.DB, upload)
+}
+
+func (r UploadRepository) renewSignedURLExec(exc Executor, upload *models.Upload) error {
+	renewed, err := r.signURL(upload)
+	if err != nil || !renewed {
+		return err
+	}
+
+	query := `
+		UPDATE "uploads"
+		SET "signed_url" = $2, "expires_at" = $3
+		WHERE "id" = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err = exc.ExecContext(ctx, query, upload.ID, upload.SignedURL, upload.ExpiresAt)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
+
+// signURL replaces an expiring download URL of upload and signs the URLs of
+// its thumbnails, which are not stored. It reports whether the stored URL was
+// replaced.
+func (r UploadRepository) signURL(upload *models.Upload) (bool, error) {
+	if r.Signer == nil {
+		return false, nil
+	}
+
+	renewed := false
+	if !upload.ExpiresAt.After(time.Now().Add(signedURLRenewBefore)) {
+		signedURL, expiresAt, err := r.Signer.SignedURL(upload.KeyFile, r.URLExpiresIn)
+		if err != nil {
+			return false, errtrace.Wrap(err)
+		}
+
+		upload.SignedURL = signedURL
+		upload.ExpiresAt = expiresAt
+		renewed = true
+	}
+
+	if len(upload.ThumbnailSizes) > 0 {
+		expiresIn := time.Until(upload.ExpiresAt)
+
+		upload.Thumbnails = make(map[string]string, len(upload.ThumbnailSizes))
+		for _, size := range upload.ThumbnailSizes {
+			signedURL, _, err := r.Signer.SignedURL(upload.ThumbnailKey(size), expiresIn)
+			if err != nil {
+				return false, errtrace.Wrap(err)
+			}
+
+			upload.Thumbnails[strconv.FormatInt(size, 10)] = signedURL
+		}
+	}
+
+	return renewed, nil
+}
+
+// uploadJoinFields are the upload columns read through a LEFT JOIN aliased as
+// "up", scanned with joinedUpload.
+const uploadJoinFields = `"up"."id", "up"."created_at", "up"."updated_at", "up"."user_id", "up"."key_file", "up"."file_name", "up"."mimetype", "up"."size", "up"."signed_url", "up"."expires_at", "up"."thumbnail_sizes"`
+
+// joinedUpload scans the nullable columns of uploadJoinFields.
+type joinedUpload struct {
+	ID             uuid.NullUUID
+	CreatedAt      sql.NullTime
+	UpdatedAt      sql.NullTime
+	UserID         uuid.NullUUID
+	KeyFile        sql.NullString
+	FileName       sql.NullString
+	MimeType       sql.NullString
+	Size           sql.NullInt64
+	SignedURL      sql.NullString
+	ExpiresAt      sql.NullTime
+	ThumbnailSizes pq.Int64Array
+}
+
+func (j *joinedUpload) dest() []any {
+	return []any{
+		&j.ID,
+		&j.CreatedAt,
+		&j.UpdatedAt,
+		&j.UserID,
+		&j.KeyFile,
+		&j.FileName,
+		&j.MimeType,
+		&j.Size,
+		&j.SignedURL,
+		&j.ExpiresAt,
+		&j.ThumbnailSizes,
+	}
+}
+
+// upload returns nil when the join matched no row.
+func (j *joinedUpload) upload() *models.Upload {
+	if !j.ID.Valid {
+		return nil
+	}
+
+	upload := &models.Upload{
+		Base: models.Base{
+			ID:        j.ID.UUID,
+			CreatedAt: j.CreatedAt.Time,
+			UpdatedAt: j.UpdatedAt.Time,
+		},
+		KeyFile:        j.KeyFile.String,
+		FileName:       j.FileName.String,
+		MimeType:       j.MimeType.String,
+		Size:           j.Size.Int64,
+		SignedURL:      j.SignedURL.String,
+		ExpiresAt:      j.ExpiresAt.Time,
+		ThumbnailSizes: j.ThumbnailSizes,
+	}
+
+	if j.UserID.Valid {
+		upload.UserID = &j.UserID.UUID
+	}
+
+	return upload
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"gintama/internal/lib/argon2"
 	"gintama/internal/models"
 
 	"braces.dev/errtrace"
@@ -16,8 +17,12 @@ import (
 	"github.com/lib/pq"
 )
 
+// UserRepository reads the avatar of users through Upload, which renews its
+// download URL, and hashes the passwords of inserted users with Password.
 type UserRepository struct {
 	BaseRepository
+	Upload   UploadRepository
+	Password *argon2.Argon2
 }
 
 func (r UserRepository) Count() (int64, error) {
@@ -32,11 +37,12 @@ func (r UserRepository) listExec(exc Executor, opts *QueryOptions) ([]*models.Us
 	selectFields := `"u"."id", "u"."created_at", "u"."updated_at", "u"."deleted_at", "u"."first_name", "u"."last_name", "u"."email", "u"."phone", "u"."active_at", "u"."blocked_at", "u"."role_id", "u"."upload_id"`
 	selectRoleFields := `"r"."id", "r"."name", "r"."created_at", "r"."updated_at"`
 	baseQuery := fmt.Sprintf(`
-		SELECT %s, %s
+		SELECT %s, %s, %s
 		FROM "users" "u"
 		LEFT JOIN "roles" "r" ON "u"."role_id" = "r"."id"
+		LEFT JOIN "uploads" "up" ON "up"."id" = "u"."upload_id" AND "up"."deleted_at" IS NULL
 		WHERE "u"."deleted_at" IS NULL
-	`, selectFields, selectRoleFields)
+	`, selectFields, selectRoleFields, uploadJoinFields)
 
 	var args []any
 	argIndex := 1
@@ -88,8 +94,9 @@ func (r UserRepository) listExec(exc Executor, opts *QueryOptions) ([]*models.Us
 	for rows.Next() {
 		user := &models.User{}
 		role := &models.Role{}
+		upload := &joinedUpload{}
 
-		err = rows.Scan(
+		dest := []any{
 			&user.ID,
 			&user.CreatedAt,
 			&user.UpdatedAt,
@@ -106,15 +113,35 @@ func (r UserRepository) listExec(exc Executor, opts *QueryOptions) ([]*models.Us
 			&role.Name,
 			&role.CreatedAt,
 			&role.UpdatedAt,
-		)
+		}
+
+		err = rows.Scan(append(dest, upload.dest()...)...)
 		if err != nil {
 			return nil, PaginationMetadata{}, errtrace.Errorf("error scanning row: %w", err)
 		}
 
 		user.Role = role
+		user.Upload = upload.upload()
 		users = append(users, user)
 	}
 
+	if err = rows.Err(); err != nil {
+		return nil, PaginationMetadata{}, errtrace.Wrap(err)
+	}
+	rows.Close()
+
+	// Renewing updates the uploads, which needs the rows to be closed first.
+	for _, user := range users {
+		if user.Upload == nil {
+			continue
+		}
+
+		err = r.Upload.renewSignedURLExec(exc, user.Upload)
+		if err != nil {
+			return nil, PaginationMetadata{}, err
+		}
+	}
+
 	count, err := r.Count()
 	if err != nil {
 		return nil, PaginationMetadata{}, errtrace.Wrap(err)
@@ -131,18 +158,20 @@ func (r UserRepository) getExec(exc Executor, id uuid.UUID) (*models.User, error
 	selectFields := `"u"."id", "u"."first_name", "u"."last_name", "u"."email", "u"."phone", "u"."active_at", "u"."blocked_at", "u"."role_id", "u"."upload_id", "u"."created_at", "u"."updated_at"`
 	selectRoleFields := `"r"."id", "r"."name", "r"."created_at", "r"."updated_at"`
 	query := fmt.Sprintf(`
-		SELECT %s, %s
+		SELECT %s, %s, %s
 		FROM "users" "u"
 		LEFT JOIN "roles" "r" ON "u"."role_id" = "r"."id"
+		LEFT JOIN "uploads" "up" ON "up"."id" = "u"."upload_id" AND "up"."deleted_at" IS NULL
 		WHERE "u"."id" = $1 AND "u"."deleted_at" IS NULL;
-	`, selectFields, selectRoleFields)
+	`, selectFields, selectRoleFields, uploadJoinFields)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
 	user := &models.User{}
 	role := &models.Role{}
-	err := exc.QueryRowContext(ctx, query, id).Scan(
+	upload := &joinedUpload{}
+	dest := []any{
 		&user.ID,
 		&user.FirstName,
 		&user.LastName,
@@ -158,7 +187,8 @@ func (r UserRepository) getExec(exc Executor, id uuid.UUID) (*models.User, error
 		&role.Name,
 		&role.CreatedAt,
 		&role.UpdatedAt,
-	)
+	}
+	err := exc.QueryRowContext(ctx, query, id).Scan(append(dest, upload.dest()...)...)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -169,6 +199,15 @@ func (r UserRepository) getExec(exc Executor, id uuid.UUID) (*models.User, error
 	}
 
 	user.Role = role
+	user.Upload = upload.upload()
+
+	if user.Upload != nil {
+		err = r.Upload.renewSignedURLExec(exc, user.Upload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return user, nil
 }
 
@@ -209,6 +248,8 @@ func (r UserRepository) getByIDExec(exc Executor, id uuid.UUID) (*models.User, e
 	return user, nil
 }
 
+// GetByEmail also returns unverified and blocked users, callers decide which
+// of them are allowed to continue.
 func (r UserRepository) GetByEmail(email string) (*models.User, error) {
 	return r.getByEmailExec(r.DB, email)
 }
@@ -217,10 +258,7 @@ func (r UserRepository) getByEmailExec(exc Executor, email string) (*models.User
 	query := `
 		SELECT "u"."id", "u"."created_at", "u"."updated_at", "u"."deleted_at", "u"."first_name", "u"."last_name", "u"."email", "u"."phone", "u"."password", "u"."active_at", "u"."blocked_at", "u"."role_id", "u"."upload_id"
 		FROM "users" AS "u"
-		WHERE "u"."email" = $1 AND
-				"u"."active_at" IS NOT NULL AND
-				"u"."blocked_at" IS NULL AND
-				"u"."deleted_at" IS NULL;
+		WHERE "u"."email" = $1 AND "u"."deleted_at" IS NULL;
 	`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -254,10 +292,52 @@ func (r UserRepository) getByEmailExec(exc Executor, email string) (*models.User
 	return user, nil
 }
 
+// GetInactiveByEmail returns a user that signed up but has not verified the
+// email address yet.
+func (r UserRepository) GetInactiveByEmail(email string) (*models.User, error) {
+	return r.getInactiveByEmailExec(r.DB, email)
+}
+
+func (r UserRepository) getInactiveByEmailExec(exc Executor, email string) (*models.User, error) {
+	query := `
+		SELECT "u"."id", "u"."created_at", "u"."updated_at", "u"."first_name", "u"."last_name", "u"."email", "u"."phone", "u"."role_id"
+		FROM "users" AS "u"
+		WHERE "u"."email" = $1 AND
+				"u"."active_at" IS NULL AND
+				"u"."blocked_at" IS NULL AND
+				"u"."deleted_at" IS NULL;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	user := &models.User{}
+	err := exc.QueryRowContext(ctx, query, email).Scan(
+		&user.ID,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.FirstName,
+		&user.LastName,
+		&user.Email,
+		&user.Phone,
+		&user.RoleID,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, errtrace.Wrap(ErrRecordNotFound)
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return user, nil
+}
+
 func (r UserRepository) Insert(users ...*models.User) error {
 	for _, user := range users {
 		if user.Password != nil {
-			if err := user.BeforeCreate(); err != nil {
+			if err := user.BeforeCreate(r.Password); err != nil {
 				return errtrace.Wrap(err)
 			}
 		}
@@ -393,6 +473,216 @@ func (r UserRepository) updateExec(exc Executor, id uuid.UUID, user *models.User
 	return nil
 }
 
+// UpdateProfile stores the fields users edit themselves, leaving the rest of
+// the row alone.
+func (r UserRepository) UpdateProfile(id uuid.UUID, user *models.User) error {
+	query := `
+		UPDATE "users"
+		SET "first_name" = $1, "last_name" = $2, "phone" = $3, "updated_at" = now()
+		WHERE "id" = $4 AND "deleted_at" IS NULL
+		RETURNING "updated_at";
+	`
+
+	args := []any{user.FirstName, user.LastName, user.Phone, id}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.DB.QueryRowContext(ctx, query, args...).Scan(&user.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetPassword returns the password hash of the user, nil when the account
+// only signs in with a provider.
+func (r UserRepository) GetPassword(id uuid.UUID) (*string, error) {
+	query := `
+		SELECT "password"
+		FROM "users"
+		WHERE "id" = $1 AND "deleted_at" IS NULL;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var password *string
+	err := r.DB.QueryRowContext(ctx, query, id).Scan(&password)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return password, nil
+}
+
+// UpdatePassword stores an already hashed password.
+func (r UserRepository) UpdatePassword(id uuid.UUID, password string) error {
+	return r.UpdatePasswordExec(r.DB, id, password)
+}
+
+func (r UserRepository) UpdatePasswordExec(exc Executor, id uuid.UUID, password string) error {
+	query := `
+		UPDATE "users"
+		SET "password" = $1, "updated_at" = now()
+		WHERE "id" = $2;
+	`
+
+	args := []any{password, id}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
+	return nil
+}
+
+// RehashPassword replaces the password hash of the user with newHash, as long
+// as it is still oldHash. It returns ErrEditConflict when the password changed
+// in the meantime.
+func (r UserRepository) RehashPassword(id uuid.UUID, oldHash string, newHash string) error {
+	query := `
+		UPDATE "users"
+		SET "password" = $1
+		WHERE "id" = $2 AND "password" = $3;
+	`
+
+	args := []any{newHash, id, oldHash}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := r.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
+	return nil
+}
+
+// SetUpload links the avatar upload of the user and returns the upload it
+// replaced, if any.
+func (r UserRepository) SetUpload(id uuid.UUID, uploadID uuid.UUID) (*uuid.UUID, error) {
+	return r.SetUploadExec(r.DB, id, uploadID)
+}
+
+func (r UserRepository) SetUploadExec(exc Executor, id uuid.UUID, uploadID uuid.UUID) (*uuid.UUID, error) {
+	query := `
+		UPDATE "users" "u"
+		SET "upload_id" = $2, "updated_at" = now()
+		FROM (SELECT "upload_id" FROM "users" WHERE "id" = $1 FOR UPDATE) "old"
+		WHERE "u"."id" = $1 AND "u"."deleted_at" IS NULL
+		RETURNING "old"."upload_id";
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var previous *uuid.UUID
+	err := exc.QueryRowContext(ctx, query, id, uploadID).Scan(&previous)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return previous, nil
+}
+
+func (r UserRepository) BlockExec(exc Executor, id uuid.UUID) error {
+	query := `
+		UPDATE "users"
+		SET "blocked_at" = COALESCE("blocked_at", now()), "updated_at" = now()
+		WHERE "id" = $1 AND "deleted_at" IS NULL;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, id)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r UserRepository) Unblock(id uuid.UUID) error {
+	return r.unblockExec(r.DB, id)
+}
+
+func (r UserRepository) unblockExec(exc Executor, id uuid.UUID) error {
+	query := `
+		UPDATE "users"
+		SET "blocked_at" = NULL, "updated_at" = now()
+		WHERE "id" = $1 AND "deleted_at" IS NULL;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, id)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
 func (r UserRepository) Delete(id uuid.UUID) error {
 	return r.BaseRepository.deleteExec(r.DB, id)
 }
@@ -401,6 +691,10 @@ func (r UserRepository) SoftDelete(id uuid.UUID) error {
 	return r.BaseRepository.softDeleteExec(r.DB, id)
 }
 
+func (r UserRepository) SoftDeleteExec(exc Executor, id uuid.UUID) error {
+	return r.BaseRepository.softDeleteExec(exc, id)
+}
+
 func (r UserRepository) Restore(id uuid.UUID) error {
 	return r.BaseRepository.restoreExec(r.DB, id)
 }
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"gintama/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/lib/pq"
+)
+
+type UserIdentityRepository struct {
+	DB *sql.DB
+}
+
+func (r UserIdentityRepository) GetByProviderSubject(provider string, subject string) (*models.UserIdentity, error) {
+	return r.getByProviderSubjectExec(r.DB, provider, subject)
+}
+
+func (r UserIdentityRepository) getByProviderSubjectExec(exc Executor, provider string, subject string) (*models.UserIdentity, error) {
+	query := `
+		SELECT "id", "created_at", "updated_at", "user_id", "provider", "subject", "email"
+		FROM "user_identities"
+		WHERE "provider" = $1 AND "subject" = $2;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	identity := &models.UserIdentity{}
+	err := exc.QueryRowContext(ctx, query, provider, subject).Scan(
+		&identity.ID,
+		&identity.CreatedAt,
+		&identity.UpdatedAt,
+		&identity.UserID,
+		&identity.Provider,
+		&identity.Subject,
+		&identity.Email,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return identity, nil
+}
+
+func (r UserIdentityRepository) Insert(identity *models.UserIdentity) error {
+	return r.InsertExec(r.DB, identity)
+}
+
+// InsertExec links an identity to a user. It returns ErrInsertDuplicate when
+// the identity is already linked or the user already has one for the provider.
+func (r UserIdentityRepository) InsertExec(exc Executor, identity *models.UserIdentity) error {
+	query := `
+		INSERT INTO "user_identities" ("id", "user_id", "provider", "subject", "email")
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING "created_at", "updated_at";
+	`
+
+	args := []any{identity.ID, identity.UserID, identity.Provider, identity.Subject, identity.Email}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := exc.QueryRowContext(ctx, query, args...).Scan(&identity.CreatedAt, &identity.UpdatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "23505" {
+				return errtrace.Wrap(ErrInsertDuplicate)
+			}
+		}
+		return errtrace.Errorf("error scanning row: %w", err)
+	}
+
+	return nil
+}
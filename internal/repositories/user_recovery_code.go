@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gintama/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type UserRecoveryCodeRepository struct {
+	DB *sql.DB
+}
+
+func (r UserRecoveryCodeRepository) InsertExec(exc Executor, codes ...*models.UserRecoveryCode) error {
+	if len(codes) == 0 {
+		return nil
+	}
+
+	columns := []string{"id", "user_id", "code"}
+
+	valueStrings := make([]string, 0, len(codes))
+	valueArgs := make([]any, 0, len(codes)*len(columns))
+
+	for i, code := range codes {
+		values := []any{code.ID, code.UserID, code.Code}
+
+		placeholders := make([]string, 0, len(values))
+		for j := range columns {
+			placeholders = append(placeholders, "$"+strconv.Itoa(i*len(columns)+j+1))
+		}
+
+		valueStrings = append(valueStrings, fmt.Sprintf("(%s)", strings.Join(placeholders, ", ")))
+		valueArgs = append(valueArgs, values...)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO "user_recovery_codes" (%s)
+		VALUES %s
+		RETURNING "id", "created_at";
+	`, strings.Join(columns[:], ", "), strings.Join(valueStrings, ", "))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, valueArgs...)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "23505" {
+				return errtrace.Wrap(ErrInsertDuplicate)
+			}
+		}
+		return errtrace.Wrap(err)
+	}
+	defer rows.Close()
+
+	for _, code := range codes {
+		if !rows.Next() {
+			return errtrace.New("error scanning row: no next row")
+		}
+
+		if err := rows.Scan(&code.ID, &code.CreatedAt); err != nil {
+			return errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Use consumes a recovery code. It returns ErrRecordNotFound when the code
+// does not exist or has already been used.
+func (r UserRecoveryCodeRepository) Use(userID uuid.UUID, code string) error {
+	return r.UseExec(r.DB, userID, code)
+}
+
+func (r UserRecoveryCodeRepository) UseExec(exc Executor, userID uuid.UUID, code string) error {
+	query := `
+		UPDATE "user_recovery_codes"
+		SET "used_at" = now()
+		WHERE "user_id" = $1 AND "code" = $2 AND "used_at" IS NULL;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, userID, code)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r UserRecoveryCodeRepository) DeleteByUserIDExec(exc Executor, userID uuid.UUID) error {
+	query := `
+		DELETE FROM "user_recovery_codes"
+		WHERE "user_id" = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := exc.ExecContext(ctx, query, userID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
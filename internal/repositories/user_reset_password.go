@@ -0,0 +1,173 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"gintama/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+)
+
+type UserResetPasswordRepository struct {
+	DB *sql.DB
+}
+
+func (r UserResetPasswordRepository) GetByToken(token string) (*models.UserResetPassword, error) {
+	return r.getByTokenExec(r.DB, token)
+}
+
+func (r UserResetPasswordRepository) getByTokenExec(exc Executor, token string) (*models.UserResetPassword, error) {
+	query := `
+		SELECT "id", "token", "expires_at"
+		FROM "user_reset_passwords"
+		WHERE "token" = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	resetPassword := &models.UserResetPassword{}
+	err := exc.QueryRowContext(ctx, query, token).Scan(&resetPassword.ID, &resetPassword.Token, &resetPassword.ExpiresAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return resetPassword, nil
+}
+
+// GetByIDForUpdate locks the row of a user until the transaction ends, so
+// concurrent requests are counted one after another.
+func (r UserResetPasswordRepository) GetByIDForUpdate(tx *sql.Tx, id uuid.UUID) (*models.UserResetPassword, error) {
+	query := `
+		SELECT "id", "token", "expires_at", "sent_count", "sent_window_started_at", "last_sent_at"
+		FROM "user_reset_passwords"
+		WHERE "id" = $1
+		FOR UPDATE;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	resetPassword := &models.UserResetPassword{}
+	err := tx.QueryRowContext(ctx, query, id).Scan(
+		&resetPassword.ID,
+		&resetPassword.Token,
+		&resetPassword.ExpiresAt,
+		&resetPassword.SentCount,
+		&resetPassword.SentWindowStartedAt,
+		&resetPassword.LastSentAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return resetPassword, nil
+}
+
+// UpsertExec stores the reset token of a user, replacing any token that was
+// issued before so only the latest emailed link stays valid.
+func (r UserResetPasswordRepository) UpsertExec(exc Executor, resetPassword *models.UserResetPassword) error {
+	query := `
+		INSERT INTO "user_reset_passwords" ("id", "token", "expires_at", "sent_count", "sent_window_started_at", "last_sent_at")
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT ("id") DO UPDATE
+		SET "token" = EXCLUDED."token",
+				"expires_at" = EXCLUDED."expires_at",
+				"sent_count" = EXCLUDED."sent_count",
+				"sent_window_started_at" = EXCLUDED."sent_window_started_at",
+				"last_sent_at" = EXCLUDED."last_sent_at";
+	`
+
+	args := []any{
+		resetPassword.ID,
+		resetPassword.Token,
+		resetPassword.ExpiresAt,
+		resetPassword.SentCount,
+		resetPassword.SentWindowStartedAt,
+		resetPassword.LastSentAt,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := exc.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
+
+func (r UserResetPasswordRepository) Delete(id uuid.UUID) error {
+	return r.DeleteExec(r.DB, id)
+}
+
+func (r UserResetPasswordRepository) DeleteExec(exc Executor, id uuid.UUID) error {
+	query := `
+		DELETE FROM "user_reset_passwords"
+		WHERE "id" = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, id)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// DeleteExpired removes up to limit reset tokens that expired before the
+// given time.
+func (r UserResetPasswordRepository) DeleteExpired(before time.Time, limit int) (int64, error) {
+	return r.deleteExpiredExec(r.DB, before, limit)
+}
+
+func (r UserResetPasswordRepository) deleteExpiredExec(exc Executor, before time.Time, limit int) (int64, error) {
+	query := `
+		DELETE FROM "user_reset_passwords"
+		WHERE "id" IN (
+			SELECT "id" FROM "user_reset_passwords" WHERE "expires_at" <= $1 LIMIT $2
+		);
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, before, limit)
+	if err != nil {
+		return 0, errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"gintama/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+)
+
+type UserTwoFactorRepository struct {
+	DB *sql.DB
+}
+
+func (r UserTwoFactorRepository) Get(id uuid.UUID) (*models.UserTwoFactor, error) {
+	return r.getExec(r.DB, id)
+}
+
+func (r UserTwoFactorRepository) getExec(exc Executor, id uuid.UUID) (*models.UserTwoFactor, error) {
+	query := `
+		SELECT "id", "created_at", "updated_at", "secret", "confirmed_at", "last_used_step"
+		FROM "user_two_factors"
+		WHERE "id" = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	twoFactor := &models.UserTwoFactor{}
+	err := exc.QueryRowContext(ctx, query, id).Scan(
+		&twoFactor.ID,
+		&twoFactor.CreatedAt,
+		&twoFactor.UpdatedAt,
+		&twoFactor.Secret,
+		&twoFactor.ConfirmedAt,
+		&twoFactor.LastUsedStep,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return twoFactor, nil
+}
+
+// Upsert stores a new pending secret. It returns ErrInsertDuplicate when the
+// user already has a confirmed authenticator, which must be disabled first.
+func (r UserTwoFactorRepository) Upsert(twoFactor *models.UserTwoFactor) error {
+	return r.upsertExec(r.DB, twoFactor)
+}
+
+func (r UserTwoFactorRepository) upsertExec(exc Executor, twoFactor *models.UserTwoFactor) error {
+	query := `
+		INSERT INTO "user_two_factors" ("id", "secret")
+		VALUES ($1, $2)
+		ON CONFLICT ("id") DO UPDATE
+		SET "secret" = EXCLUDED."secret",
+				"confirmed_at" = NULL,
+				"last_used_step" = 0,
+				"updated_at" = now()
+		WHERE "user_two_factors"."confirmed_at" IS NULL
+		RETURNING "created_at", "updated_at";
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := exc.QueryRowContext(ctx, query, twoFactor.ID, twoFactor.Secret).Scan(&twoFactor.CreatedAt, &twoFactor.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return errtrace.Wrap(ErrInsertDuplicate)
+		default:
+			return errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ConfirmExec activates a pending secret and records the step of the code
+// that confirmed it.
+func (r UserTwoFactorRepository) ConfirmExec(exc Executor, id uuid.UUID, step int64) error {
+	query := `
+		UPDATE "user_two_factors"
+		SET "confirmed_at" = now(), "last_used_step" = $2, "updated_at" = now()
+		WHERE "id" = $1 AND "confirmed_at" IS NULL;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, id, step)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
+	return nil
+}
+
+// UseStep records a successfully validated code. It returns ErrEditConflict
+// when the code's step was already used, meaning the code is being replayed.
+func (r UserTwoFactorRepository) UseStep(id uuid.UUID, step int64) error {
+	return r.UseStepExec(r.DB, id, step)
+}
+
+func (r UserTwoFactorRepository) UseStepExec(exc Executor, id uuid.UUID, step int64) error {
+	query := `
+		UPDATE "user_two_factors"
+		SET "last_used_step" = $2, "updated_at" = now()
+		WHERE "id" = $1 AND "last_used_step" < $2;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, id, step)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
+	return nil
+}
+
+func (r UserTwoFactorRepository) DeleteExec(exc Executor, id uuid.UUID) error {
+	query := `
+		DELETE FROM "user_two_factors"
+		WHERE "id" = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := exc.ExecContext(ctx, query, id)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
@@ -20,6 +20,7 @@ type UserVerifyAccountRepository struct {
 	DB *sql.DB
 }
 
+// Get looks the pending verification of a user up by the digest of its token.
 func (r UserVerifyAccountRepository) Get(id uuid.UUID, token string) (*models.UserVerifyAccount, error) {
 	return r.getExec(r.DB, id, token)
 }
@@ -48,6 +49,74 @@ func (r UserVerifyAccountRepository) getExec(exc Executor, id uuid.UUID, token s
 	return user, nil
 }
 
+// GetByIDForUpdate locks the row of a user until the transaction ends, so
+// concurrent resends are counted one after another.
+func (r UserVerifyAccountRepository) GetByIDForUpdate(tx *sql.Tx, id uuid.UUID) (*models.UserVerifyAccount, error) {
+	query := `
+		SELECT "id", "token", "expires_at", "sent_count", "sent_window_started_at", "last_sent_at"
+		FROM "user_verify_accounts"
+		WHERE "id" = $1
+		FOR UPDATE;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	user := &models.UserVerifyAccount{}
+	err := tx.QueryRowContext(ctx, query, id).Scan(
+		&user.ID,
+		&user.Token,
+		&user.ExpiresAt,
+		&user.SentCount,
+		&user.SentWindowStartedAt,
+		&user.LastSentAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return user, nil
+}
+
+// UpsertExec replaces the pending token of a user, which invalidates any
+// verification link sent before.
+func (r UserVerifyAccountRepository) UpsertExec(exc Executor, user *models.UserVerifyAccount) error {
+	query := `
+		INSERT INTO "user_verify_accounts" ("id", "token", "expires_at", "sent_count", "sent_window_started_at", "last_sent_at")
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT ("id") DO UPDATE
+		SET "token" = EXCLUDED."token",
+				"expires_at" = EXCLUDED."expires_at",
+				"sent_count" = EXCLUDED."sent_count",
+				"sent_window_started_at" = EXCLUDED."sent_window_started_at",
+				"last_sent_at" = EXCLUDED."last_sent_at";
+	`
+
+	args := []any{
+		user.ID,
+		user.Token,
+		user.ExpiresAt,
+		user.SentCount,
+		user.SentWindowStartedAt,
+		user.LastSentAt,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := exc.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
+
 func (r UserVerifyAccountRepository) Insert(users ...*models.UserVerifyAccount) error {
 	return r.InsertExec(r.DB, users...)
 }
@@ -57,13 +126,13 @@ func (r UserVerifyAccountRepository) InsertExec(exc Executor, users ...*models.U
 		return nil
 	}
 
-	columns := []string{"id", "token", "expires_at"}
+	columns := []string{"id", "token", "expires_at", "sent_count", "sent_window_started_at", "last_sent_at"}
 
 	valueStrings := make([]string, 0, len(users))
 	valueArgs := make([]any, 0, len(users)*len(columns))
 
 	for i, user := range users {
-		values := []any{user.ID, user.Token, user.ExpiresAt}
+		values := []any{user.ID, user.Token, user.ExpiresAt, user.SentCount, user.SentWindowStartedAt, user.LastSentAt}
 
 		placeholders := make([]string, 0, len(values))
 		for j := range columns {
@@ -106,3 +175,33 @@ func (r UserVerifyAccountRepository) InsertExec(exc Executor, users ...*models.U
 
 	return nil
 }
+
+// DeleteExpired removes up to limit pending verifications whose link expired
+// before the given time.
+func (r UserVerifyAccountRepository) DeleteExpired(before time.Time, limit int) (int64, error) {
+	return r.deleteExpiredExec(r.DB, before, limit)
+}
+
+func (r UserVerifyAccountRepository) deleteExpiredExec(exc Executor, before time.Time, limit int) (int64, error) {
+	query := `
+		DELETE FROM "user_verify_accounts"
+		WHERE "id" IN (
+			SELECT "id" FROM "user_verify_accounts" WHERE "expires_at" <= $1 LIMIT $2
+		);
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, before, limit)
+	if err != nil {
+		return 0, errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
@@ -0,0 +1,64 @@
+package seeders
+
+import (
+	"database/sql"
+
+	"gintama/internal/lib/constant"
+	"gintama/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+// RolePermissionSeeder grants the permissions created by the migrations to
+// the default roles, so Admin keeps full access and User keeps read access and
+// uploads.
+type RolePermissionSeeder struct {
+	DB *sql.DB
+}
+
+func (s RolePermissionSeeder) Name() string {
+	return "role permission"
+}
+
+func (s RolePermissionSeeder) Seed() {
+	grants := map[string][]string{
+		constant.RoleAdmin: {
+			constant.PermissionUsersRead,
+			constant.PermissionUsersWrite,
+			constant.PermissionRolesRead,
+			constant.PermissionRolesWrite,
+			constant.PermissionSessionsRead,
+			constant.PermissionSessionsWrite,
+			constant.PermissionPermissionsRead,
+			constant.PermissionPermissionsWrite,
+			constant.PermissionUploadsWrite,
+		},
+		constant.RoleUser: {
+			constant.PermissionUsersRead,
+			constant.PermissionRolesRead,
+			constant.PermissionUploadsWrite,
+		},
+	}
+
+	permissionRepo := repositories.PermissionRepository{
+		BaseRepository: repositories.BaseRepository{
+			DB:        s.DB,
+			TableName: "permissions",
+		},
+	}
+	rolePermissionRepo := repositories.RolePermissionRepository{DB: s.DB}
+
+	for roleID, names := range grants {
+		for _, name := range names {
+			permission, err := permissionRepo.GetByName(name)
+			if err != nil {
+				panic(NewErrSeedingFailed(err))
+			}
+
+			err = rolePermissionRepo.Attach(uuid.MustParse(roleID), permission.ID)
+			if err != nil {
+				panic(NewErrSeedingFailed(err))
+			}
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"gintama/internal/lib"
+	"gintama/internal/lib/argon2"
 	"gintama/internal/lib/constant"
 	"gintama/internal/models"
 	"gintama/internal/repositories"
@@ -55,6 +56,7 @@ func (s UserSeeder) Seed() {
 			DB:        s.DB,
 			TableName: "users",
 		},
+		Password: argon2.New(),
 	}
 	err := userRepo.Insert(users...)
 	if err != nil {
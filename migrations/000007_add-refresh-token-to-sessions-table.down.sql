@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_sessions_family_id;
+DROP INDEX IF EXISTS idx_sessions_refresh_token;
+DROP INDEX IF EXISTS idx_sessions_rotated_at;
+
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "family_id";
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "refresh_token";
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "refresh_expires_at";
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "rotated_at";
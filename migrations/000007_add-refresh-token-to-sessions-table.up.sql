@@ -0,0 +1,14 @@
+ALTER TABLE "sessions" ADD COLUMN IF NOT EXISTS "family_id" UUID;
+ALTER TABLE "sessions" ADD COLUMN IF NOT EXISTS "refresh_token" VARCHAR; -- NULL for sessions created before refresh tokens
+ALTER TABLE "sessions" ADD COLUMN IF NOT EXISTS "refresh_expires_at" TIMESTAMP;
+ALTER TABLE "sessions" ADD COLUMN IF NOT EXISTS "rotated_at" TIMESTAMP; -- set once the refresh token has been exchanged
+
+UPDATE "sessions" SET "family_id" = "id" WHERE "family_id" IS NULL;
+UPDATE "sessions" SET "refresh_expires_at" = "expires_at" WHERE "refresh_expires_at" IS NULL;
+
+ALTER TABLE "sessions" ALTER COLUMN "family_id" SET NOT NULL;
+ALTER TABLE "sessions" ALTER COLUMN "refresh_expires_at" SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_sessions_family_id ON "sessions" ("family_id");
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_refresh_token ON "sessions" ("refresh_token");
+CREATE INDEX IF NOT EXISTS idx_sessions_rotated_at ON "sessions" ("rotated_at");
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_user_reset_passwords_token;
+
+DROP TABLE IF EXISTS "user_reset_passwords";
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS "user_reset_passwords" (
+  "id" UUID PRIMARY KEY NOT NULL DEFAULT uuidv7(), -- Polymorphic ID (User ID)
+  "token" TEXT NOT NULL, -- SHA-256 digest of the emailed token
+  "expires_at" TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_reset_passwords_token ON "user_reset_passwords" ("token");
+
+-- Polymorphic table
+ALTER TABLE "user_reset_passwords" ADD FOREIGN KEY ("id") REFERENCES "users" ("id") ON DELETE CASCADE;
@@ -0,0 +1,3 @@
+ALTER TABLE "user_verify_accounts" DROP COLUMN IF EXISTS "sent_count";
+ALTER TABLE "user_verify_accounts" DROP COLUMN IF EXISTS "sent_window_started_at";
+ALTER TABLE "user_verify_accounts" DROP COLUMN IF EXISTS "last_sent_at";
@@ -0,0 +1,3 @@
+ALTER TABLE "user_verify_accounts" ADD COLUMN IF NOT EXISTS "sent_count" INTEGER NOT NULL DEFAULT 1; -- emails sent in the current window
+ALTER TABLE "user_verify_accounts" ADD COLUMN IF NOT EXISTS "sent_window_started_at" TIMESTAMP NOT NULL DEFAULT now();
+ALTER TABLE "user_verify_accounts" ADD COLUMN IF NOT EXISTS "last_sent_at" TIMESTAMP NOT NULL DEFAULT now();
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_user_recovery_codes_user_id;
+DROP INDEX IF EXISTS idx_user_recovery_codes_user_id_code;
+
+DROP TABLE IF EXISTS "user_recovery_codes";
+
+DROP INDEX IF EXISTS idx_user_two_factors_confirmed_at;
+
+DROP TABLE IF EXISTS "user_two_factors";
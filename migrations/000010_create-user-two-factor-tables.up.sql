@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS "user_two_factors" (
+  "id" UUID PRIMARY KEY NOT NULL DEFAULT uuidv7(), -- Polymorphic ID (User ID)
+  "created_at" TIMESTAMP DEFAULT now(),
+  "updated_at" TIMESTAMP DEFAULT now(),
+  "secret" TEXT NOT NULL, -- base32 TOTP secret
+  "confirmed_at" TIMESTAMP, -- NULL until the user proves the authenticator works
+  "last_used_step" BIGINT NOT NULL DEFAULT 0 -- rejects replayed codes
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_two_factors_confirmed_at ON "user_two_factors" ("confirmed_at");
+
+-- Polymorphic table
+ALTER TABLE "user_two_factors" ADD FOREIGN KEY ("id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE TABLE IF NOT EXISTS "user_recovery_codes" (
+  "id" UUID PRIMARY KEY NOT NULL DEFAULT uuidv7(),
+  "created_at" TIMESTAMP DEFAULT now(),
+  "user_id" UUID NOT NULL,
+  "code" TEXT NOT NULL, -- SHA-256 digest of the normalized code
+  "used_at" TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON "user_recovery_codes" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id_code ON "user_recovery_codes" ("user_id", "code");
+
+ALTER TABLE "user_recovery_codes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
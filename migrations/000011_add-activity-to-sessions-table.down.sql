@@ -0,0 +1,2 @@
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "signed_in_at";
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "last_seen_at";
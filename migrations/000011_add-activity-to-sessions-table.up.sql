@@ -0,0 +1,8 @@
+ALTER TABLE "sessions" ADD COLUMN IF NOT EXISTS "signed_in_at" TIMESTAMP; -- carried over when the refresh token is rotated
+ALTER TABLE "sessions" ADD COLUMN IF NOT EXISTS "last_seen_at" TIMESTAMP;
+
+UPDATE "sessions" SET "signed_in_at" = "created_at" WHERE "signed_in_at" IS NULL;
+UPDATE "sessions" SET "last_seen_at" = "created_at" WHERE "last_seen_at" IS NULL;
+
+ALTER TABLE "sessions" ALTER COLUMN "signed_in_at" SET NOT NULL;
+ALTER TABLE "sessions" ALTER COLUMN "last_seen_at" SET NOT NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_login_attempts_last_failed_at;
+DROP INDEX IF EXISTS idx_login_attempts_locked_until;
+
+DROP TABLE IF EXISTS "login_attempts";
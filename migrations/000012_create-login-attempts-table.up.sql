@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS "login_attempts" (
+  "key" VARCHAR PRIMARY KEY NOT NULL, -- "email:<address>" or "ip:<address>"
+  "created_at" TIMESTAMP DEFAULT now(),
+  "updated_at" TIMESTAMP DEFAULT now(),
+  "failures" INT NOT NULL DEFAULT 0,
+  "window_started_at" TIMESTAMP NOT NULL,
+  "last_failed_at" TIMESTAMP NOT NULL,
+  "locked_until" TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_last_failed_at ON "login_attempts" ("last_failed_at");
+CREATE INDEX IF NOT EXISTS idx_login_attempts_locked_until ON "login_attempts" ("locked_until");
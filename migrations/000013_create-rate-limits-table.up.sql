@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS "rate_limits" (
+  "key" VARCHAR PRIMARY KEY NOT NULL,
+  "count" INT NOT NULL DEFAULT 0,
+  "reset_at" TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limits_reset_at ON "rate_limits" ("reset_at");
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_role_permissions_permission_id;
+
+DROP TABLE IF EXISTS "role_permissions";
+
+DROP INDEX IF EXISTS idx_permissions_created_at;
+DROP INDEX IF EXISTS idx_permissions_name;
+
+DROP TABLE IF EXISTS "permissions";
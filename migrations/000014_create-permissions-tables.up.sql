@@ -0,0 +1,49 @@
+CREATE TABLE IF NOT EXISTS "permissions" (
+  "id" UUID PRIMARY KEY NOT NULL DEFAULT uuidv7(),
+  "created_at" TIMESTAMP DEFAULT now(),
+  "updated_at" TIMESTAMP DEFAULT now(),
+  "name" VARCHAR NOT NULL, -- "<resource>:<action>", e.g. "users:write"
+  "description" TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_permissions_created_at ON "permissions" ("created_at");
+CREATE UNIQUE INDEX IF NOT EXISTS idx_permissions_name ON "permissions" ("name");
+
+CREATE TABLE IF NOT EXISTS "role_permissions" (
+  "role_id" UUID NOT NULL,
+  "permission_id" UUID NOT NULL,
+  "created_at" TIMESTAMP DEFAULT now(),
+  PRIMARY KEY ("role_id", "permission_id")
+);
+
+CREATE INDEX IF NOT EXISTS idx_role_permissions_permission_id ON "role_permissions" ("permission_id");
+
+ALTER TABLE "role_permissions" ADD FOREIGN KEY ("role_id") REFERENCES "roles" ("id") ON DELETE CASCADE;
+ALTER TABLE "role_permissions" ADD FOREIGN KEY ("permission_id") REFERENCES "permissions" ("id") ON DELETE CASCADE;
+
+INSERT INTO "permissions" ("name", "description") VALUES
+  ('users:read', 'List and view users'),
+  ('users:write', 'Create, update, delete, block and unlock users'),
+  ('roles:read', 'List and view roles'),
+  ('roles:write', 'Create, update and delete roles and their permissions'),
+  ('sessions:read', 'List the sessions of every user'),
+  ('permissions:read', 'List and view permissions'),
+  ('permissions:write', 'Create, update and delete permissions')
+ON CONFLICT ("name") DO NOTHING;
+
+-- Keep the access of existing deployments: Admin gets every permission, User
+-- keeps the read access every signed-in user had. Fresh databases get the same
+-- grants from the role permission seeder, as the roles do not exist yet.
+INSERT INTO "role_permissions" ("role_id", "permission_id")
+SELECT "r"."id", "p"."id"
+FROM "roles" "r"
+CROSS JOIN "permissions" "p"
+WHERE "r"."id" = '019a626e-f0a0-74c9-bee7-51aaeeec4b00'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO "role_permissions" ("role_id", "permission_id")
+SELECT "r"."id", "p"."id"
+FROM "roles" "r"
+CROSS JOIN "permissions" "p"
+WHERE "r"."id" = '019a626e-f0a0-780b-a643-a1ee0220d079' AND "p"."name" IN ('users:read', 'roles:read')
+ON CONFLICT DO NOTHING;
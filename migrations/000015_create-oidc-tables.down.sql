@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_user_identities_user_id_provider;
+DROP INDEX IF EXISTS idx_user_identities_provider_subject;
+DROP INDEX IF EXISTS idx_user_identities_user_id;
+
+DROP TABLE IF EXISTS "user_identities";
+
+DROP INDEX IF EXISTS idx_oidc_states_expires_at;
+
+DROP TABLE IF EXISTS "oidc_states";
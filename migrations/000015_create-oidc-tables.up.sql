@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS "oidc_states" (
+  "id" VARCHAR PRIMARY KEY NOT NULL, -- SHA-256 digest of the state parameter
+  "created_at" TIMESTAMP DEFAULT now(),
+  "provider" VARCHAR NOT NULL,
+  "nonce" TEXT NOT NULL,
+  "code_verifier" TEXT NOT NULL, -- PKCE verifier, never sent to the browser
+  "expires_at" TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_oidc_states_expires_at ON "oidc_states" ("expires_at");
+
+CREATE TABLE IF NOT EXISTS "user_identities" (
+  "id" UUID PRIMARY KEY NOT NULL DEFAULT uuidv7(),
+  "created_at" TIMESTAMP DEFAULT now(),
+  "updated_at" TIMESTAMP DEFAULT now(),
+  "user_id" UUID NOT NULL,
+  "provider" VARCHAR NOT NULL,
+  "subject" VARCHAR NOT NULL, -- "sub" claim, stable per provider
+  "email" VARCHAR -- email reported by the provider when the identity was linked
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON "user_identities" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_identities_provider_subject ON "user_identities" ("provider", "subject");
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_identities_user_id_provider ON "user_identities" ("user_id", "provider");
+
+ALTER TABLE "user_identities" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
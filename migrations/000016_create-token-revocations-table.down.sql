@@ -0,0 +1,9 @@
+DELETE FROM "permissions" WHERE "name" = 'sessions:write';
+
+DROP TRIGGER IF EXISTS trg_sessions_revoke_deleted ON "sessions";
+DROP FUNCTION IF EXISTS revoke_deleted_session();
+
+DROP INDEX IF EXISTS idx_token_revocations_expires_at;
+DROP INDEX IF EXISTS idx_token_revocations_updated_at;
+
+DROP TABLE IF EXISTS "token_revocations";
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS "token_revocations" (
+  "key" VARCHAR PRIMARY KEY NOT NULL, -- "jti:<id>", "session:<id>" or "user:<id>"
+  "created_at" TIMESTAMP DEFAULT now(),
+  "updated_at" TIMESTAMP DEFAULT now(), -- sync watermark of the in-memory revocation list
+  "revoked_before" TIMESTAMP NOT NULL, -- tokens issued up to this time are revoked
+  "expires_at" TIMESTAMP NOT NULL, -- every revoked token has expired by then
+  "reason" VARCHAR
+);
+
+CREATE INDEX IF NOT EXISTS idx_token_revocations_updated_at ON "token_revocations" ("updated_at");
+CREATE INDEX IF NOT EXISTS idx_token_revocations_expires_at ON "token_revocations" ("expires_at");
+
+-- Deleting a session revokes its access token, whichever code path removed
+-- the row (sign out, password reset, blocking the user, cascades).
+CREATE OR REPLACE FUNCTION revoke_deleted_session() RETURNS TRIGGER AS $$
+BEGIN
+  INSERT INTO "token_revocations" ("key", "revoked_before", "expires_at", "reason")
+  VALUES ('session:' || OLD."id", OLD."expires_at", OLD."expires_at", 'session deleted')
+  ON CONFLICT ("key") DO UPDATE
+  SET "revoked_before" = GREATEST("token_revocations"."revoked_before", EXCLUDED."revoked_before"),
+      "expires_at" = GREATEST("token_revocations"."expires_at", EXCLUDED."expires_at"),
+      "updated_at" = now();
+
+  RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_sessions_revoke_deleted
+AFTER DELETE ON "sessions"
+FOR EACH ROW EXECUTE FUNCTION revoke_deleted_session();
+
+INSERT INTO "permissions" ("name", "description") VALUES
+  ('sessions:write', 'Revoke the sessions and tokens of every user')
+ON CONFLICT ("name") DO NOTHING;
+
+INSERT INTO "role_permissions" ("role_id", "permission_id")
+SELECT "r"."id", "p"."id"
+FROM "roles" "r"
+CROSS JOIN "permissions" "p"
+WHERE "r"."id" = '019a626e-f0a0-74c9-bee7-51aaeeec4b00' AND "p"."name" = 'sessions:write'
+ON CONFLICT DO NOTHING;
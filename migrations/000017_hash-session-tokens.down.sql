@@ -0,0 +1,5 @@
+-- Digests cannot be turned back into tokens, so every session is signed out
+-- and pending verifications have to be resent.
+
+DELETE FROM "sessions";
+DELETE FROM "user_verify_accounts";
@@ -0,0 +1,4 @@
+-- Tokens are stored as the hex encoded SHA-256 digest, the same as lib.HashToken.
+UPDATE "sessions" SET "token" = encode(sha256(convert_to("token", 'UTF8')), 'hex');
+UPDATE "sessions" SET "refresh_token" = encode(sha256(convert_to("refresh_token", 'UTF8')), 'hex') WHERE "refresh_token" IS NOT NULL;
+UPDATE "user_verify_accounts" SET "token" = encode(sha256(convert_to("token", 'UTF8')), 'hex');
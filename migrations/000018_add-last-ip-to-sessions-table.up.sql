@@ -0,0 +1,5 @@
+ALTER TABLE "sessions" ADD COLUMN IF NOT EXISTS "last_ip" VARCHAR; -- recorded together with last_seen_at
+
+UPDATE "sessions" SET "last_ip" = "ip_address" WHERE "last_ip" IS NULL;
+
+ALTER TABLE "sessions" ALTER COLUMN "last_ip" SET NOT NULL;
@@ -0,0 +1,12 @@
+CREATE OR REPLACE FUNCTION revoke_deleted_session() RETURNS TRIGGER AS $$
+BEGIN
+  INSERT INTO "token_revocations" ("key", "revoked_before", "expires_at", "reason")
+  VALUES ('session:' || OLD."id", OLD."expires_at", OLD."expires_at", 'session deleted')
+  ON CONFLICT ("key") DO UPDATE
+  SET "revoked_before" = GREATEST("token_revocations"."revoked_before", EXCLUDED."revoked_before"),
+      "expires_at" = GREATEST("token_revocations"."expires_at", EXCLUDED."expires_at"),
+      "updated_at" = now();
+
+  RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
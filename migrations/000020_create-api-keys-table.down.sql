@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_api_keys_key_hash;
+DROP INDEX IF EXISTS idx_api_keys_user_id;
+
+DROP TABLE IF EXISTS "api_keys";
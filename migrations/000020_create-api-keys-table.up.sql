@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS "api_keys" (
+  "id" UUID PRIMARY KEY NOT NULL DEFAULT uuidv7(),
+  "created_at" TIMESTAMP DEFAULT now(),
+  "updated_at" TIMESTAMP DEFAULT now(),
+  "user_id" UUID NOT NULL,
+  "name" VARCHAR NOT NULL,
+  "prefix" VARCHAR NOT NULL, -- first characters of the key, shown to tell keys apart
+  "key_hash" VARCHAR NOT NULL, -- SHA-256 digest of the key
+  "scopes" TEXT[] NOT NULL DEFAULT '{}', -- permission names, capped by the role of the user
+  "expires_at" TIMESTAMP, -- NULL for keys that never expire
+  "last_used_at" TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON "api_keys" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON "api_keys" ("key_hash");
+
+ALTER TABLE "api_keys" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
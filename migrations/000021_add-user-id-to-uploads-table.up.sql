@@ -0,0 +1,12 @@
+ALTER TABLE "uploads" ADD COLUMN IF NOT EXISTS "user_id" UUID; -- uploader, NULL for uploads made before it was recorded
+
+-- Existing uploads belong to the users showing them as avatar.
+UPDATE "uploads" SET "user_id" = "u"."id" FROM "users" "u" WHERE "u"."upload_id" = "uploads"."id";
+
+CREATE INDEX IF NOT EXISTS idx_uploads_user_id ON "uploads" ("user_id");
+
+ALTER TABLE "uploads" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+-- Deleting an upload unlinks it from the user instead of deleting the user.
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "users_upload_id_fkey";
+ALTER TABLE "users" ADD CONSTRAINT "users_upload_id_fkey" FOREIGN KEY ("upload_id") REFERENCES "uploads" ("id") ON DELETE SET NULL;
@@ -0,0 +1 @@
+ALTER TABLE "uploads" ADD COLUMN IF NOT EXISTS "thumbnail_sizes" INTEGER[] NOT NULL DEFAULT '{}'; -- widths in pixels of the thumbnails stored next to the file
@@ -0,0 +1,6 @@
+ALTER TABLE "uploads" ALTER COLUMN "size" TYPE INTEGER;
+
+DROP INDEX IF EXISTS idx_resumable_uploads_expires_at;
+DROP INDEX IF EXISTS idx_resumable_uploads_user_id;
+
+DROP TABLE IF EXISTS "resumable_uploads";
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS "resumable_uploads" (
+  "id" UUID PRIMARY KEY NOT NULL DEFAULT uuidv7(),
+  "created_at" TIMESTAMP DEFAULT now(),
+  "updated_at" TIMESTAMP DEFAULT now(),
+  "user_id" UUID NOT NULL,
+  "key_file" TEXT NOT NULL, -- key of the upload once completed
+  "file_name" TEXT NOT NULL,
+  "mimetype" VARCHAR NOT NULL DEFAULT '', -- detected from the first chunk
+  "size" BIGINT NOT NULL, -- in bytes, declared by the client
+  "upload_offset" BIGINT NOT NULL DEFAULT 0, -- bytes received so far
+  "multipart_id" TEXT NOT NULL DEFAULT '', -- id of the multipart upload in storage, set with the first chunk
+  "part_etags" TEXT[] NOT NULL DEFAULT '{}', -- ETags of the stored parts, numbered from 1
+  "checksum" VARCHAR NOT NULL, -- SHA-256 of the whole file, hex encoded, declared by the client
+  "hash_state" BYTEA NOT NULL, -- SHA-256 state over the bytes received so far
+  "locked_until" TIMESTAMP, -- set while a chunk is being received
+  "expires_at" TIMESTAMP NOT NULL -- abandoned uploads are aborted after it
+);
+
+CREATE INDEX IF NOT EXISTS idx_resumable_uploads_user_id ON "resumable_uploads" ("user_id");
+CREATE INDEX IF NOT EXISTS idx_resumable_uploads_expires_at ON "resumable_uploads" ("expires_at");
+
+ALTER TABLE "resumable_uploads" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+-- Completed resumable uploads can exceed 2 GiB.
+ALTER TABLE "uploads" ALTER COLUMN "size" TYPE BIGINT;
@@ -0,0 +1,11 @@
+INSERT INTO "permissions" ("name", "description") VALUES
+  ('uploads:write', 'Upload files and manage own uploads')
+ON CONFLICT ("name") DO NOTHING;
+
+-- Every signed-in user could upload before, so both default roles keep it.
+INSERT INTO "role_permissions" ("role_id", "permission_id")
+SELECT "r"."id", "p"."id"
+FROM "roles" "r"
+CROSS JOIN "permissions" "p"
+WHERE "r"."id" IN ('019a626e-f0a0-74c9-bee7-51aaeeec4b00', '019a626e-f0a0-780b-a643-a1ee0220d079') AND "p"."name" = 'uploads:write'
+ON CONFLICT DO NOTHING;